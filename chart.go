@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// chartSeries is one line of a braille chart. Earlier series win when two
+// lines share a cell.
+type chartSeries struct {
+	values []float64
+	style  lipgloss.Style
+}
+
+// brailleBits maps a dot inside a braille cell, indexed [row][column], to
+// its bit in the U+2800 block.
+var brailleBits = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+type brailleCanvas struct {
+	width, height int
+	bits          [][]rune
+	owner         [][]int
+}
+
+func newBrailleCanvas(width, height int) *brailleCanvas {
+	c := &brailleCanvas{width: width, height: height}
+	c.bits = make([][]rune, height)
+	c.owner = make([][]int, height)
+	for y := range c.bits {
+		c.bits[y] = make([]rune, width)
+		c.owner[y] = make([]int, width)
+		for x := range c.owner[y] {
+			c.owner[y][x] = -1
+		}
+	}
+	return c
+}
+
+// set lights the dot at (x, y) in dot coordinates, y growing downwards.
+func (c *brailleCanvas) set(x, y, series int) {
+	if x < 0 || y < 0 || x >= c.width*2 || y >= c.height*4 {
+		return
+	}
+	cx, cy := x/2, y/4
+	c.bits[cy][cx] |= brailleBits[y%4][x%2]
+	if o := c.owner[cy][cx]; o < 0 || series < o {
+		c.owner[cy][cx] = series
+	}
+}
+
+// line draws from (x0, y0) to (x1, y1) with Bresenham's algorithm.
+func (c *brailleCanvas) line(x0, y0, x1, y1, series int) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		c.set(x0, y0, series)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// renderChart plots the series as braille lines over width by height
+// cells, with a value axis on the left, second markers below, and an
+// error marker under every second listed in errors.
+func renderChart(series []chartSeries, errors []int, width, height int, axis, errStyle lipgloss.Style) string {
+	points := 0
+	top := 0.0
+	for _, s := range series {
+		points = max(points, len(s.values))
+		for _, v := range s.values {
+			top = math.Max(top, v)
+		}
+	}
+	if points == 0 || width < 8 || height < 1 {
+		return ""
+	}
+	top = math.Max(10, math.Ceil(top/10)*10)
+
+	labels := []string{fmt.Sprintf("%.0f", top), "0"}
+	gutter := max(len(labels[0]), len(labels[1])) + 1
+	plotWidth := width - gutter
+	c := newBrailleCanvas(plotWidth, height)
+	dotsX, dotsY := plotWidth*2, height*4
+	xOf := func(i int) int {
+		if points < 2 {
+			return 0
+		}
+		return i * (dotsX - 1) / (points - 1)
+	}
+	yOf := func(v float64) int {
+		return dotsY - 1 - int(math.Round(v/top*float64(dotsY-1)))
+	}
+	for si, s := range series {
+		for i, v := range s.values {
+			if i == 0 {
+				c.set(xOf(0), yOf(v), si)
+				continue
+			}
+			c.line(xOf(i-1), yOf(s.values[i-1]), xOf(i), yOf(v), si)
+		}
+	}
+
+	var b strings.Builder
+	for y := 0; y < height; y++ {
+		label := ""
+		switch y {
+		case 0:
+			label = labels[0]
+		case height - 1:
+			label = labels[1]
+		}
+		b.WriteString(axis.Render(fmt.Sprintf("%*s ", gutter-1, label)))
+		for x := 0; x < plotWidth; x++ {
+			if c.bits[y][x] == 0 {
+				b.WriteByte(' ')
+				continue
+			}
+			b.WriteString(series[c.owner[y][x]].style.Render(string(0x2800 + c.bits[y][x])))
+		}
+		b.WriteByte('\n')
+	}
+
+	marks := []rune(strings.Repeat(" ", plotWidth))
+	for i, n := range errors {
+		if n > 0 && i < points {
+			marks[xOf(i)/2] = '×'
+		}
+	}
+	b.WriteString(strings.Repeat(" ", gutter) + errStyle.Render(string(marks)) + "\n")
+
+	last := fmt.Sprintf("%ds", points)
+	pad := max(plotWidth-2-len(last), 1)
+	b.WriteString(axis.Render(strings.Repeat(" ", gutter) + "1s" + strings.Repeat(" ", pad) + last))
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestRenderChart(t *testing.T) {
+	plain := lipgloss.NewStyle()
+	out := renderChart([]chartSeries{{values: []float64{0, 50, 100}, style: plain}}, []int{0, 2, 0}, 20, 3, plain, plain)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("chart has %d lines, want 3 rows, errors and axis:\n%s", len(lines), out)
+	}
+	if !strings.HasPrefix(lines[0], "100 ") || !strings.HasPrefix(lines[2], "  0 ") {
+		t.Fatalf("value axis missing:\n%s", out)
+	}
+	if !strings.ContainsRune(lines[3], '×') || !strings.HasSuffix(lines[4], "3s") {
+		t.Fatalf("error markers or time axis missing:\n%s", out)
+	}
+	if renderChart(nil, nil, 20, 3, plain, plain) != "" {
+		t.Fatal("empty chart should render nothing")
+	}
+}
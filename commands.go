@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// command is a non-interactive subcommand such as `tuiper stats`. run
+// receives the arguments after the command name and returns the process
+// exit code.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{name: "stats", summary: "report on saved session results", run: runStats},
+	{name: "replay", summary: "play back a recorded session in the TUI", run: runReplay},
+	{name: "heatmap", summary: "show the keys and bigrams that slow you down", run: runHeatmap},
+	{name: "config", summary: "create, check or print the config file", run: runConfig},
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func printCommands(out io.Writer) {
+	fmt.Fprintln(out, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(out, "\nRun '%s <command> -h' for command options.\n", strings.ToLower(appName))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tuitype/internal/config"
+)
+
+//go:embed tuiper.example.json
+var exampleConfig []byte
+
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	name := strings.ToLower(appName)
+	usage := func() {
+		fmt.Fprintf(stderr, "Usage:\n  %[1]s config init [-config path]\n  %[1]s config validate [file ...]\n  %[1]s config show [-config path]\n\n", name)
+		fmt.Fprintln(stderr, "Commands:")
+		fmt.Fprintln(stderr, "  init      write the example config unless the file exists")
+		fmt.Fprintln(stderr, "  validate  report every problem of config files")
+		fmt.Fprintln(stderr, "  show      print the resolved config with the source of each value")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "init":
+		return runConfigInit(args[1:], stdout, stderr)
+	case "validate":
+		return runConfigValidate(args[1:], stdout, stderr)
+	case "show":
+		return runConfigShow(args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		usage()
+		return 0
+	}
+	fmt.Fprintf(stderr, "config: unknown command %q\n", args[0])
+	usage()
+	return 2
+}
+
+// configFlags parses the -config flag of a config command.
+func configFlags(cmd string, args []string, stderr io.Writer) (string, int, bool) {
+	fs := flag.NewFlagSet("config "+cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("config", defaultConfigPath(), "path to JSON config file")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:\n  %s config %s [options]\n\nOptions:\n", strings.ToLower(appName), cmd)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return "", 0, false
+		}
+		return "", 2, false
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return "", 2, false
+	}
+	return *path, 0, true
+}
+
+func runConfigInit(args []string, stdout, stderr io.Writer) int {
+	path, code, ok := configFlags("init", args, stderr)
+	if !ok {
+		return code
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Fprintf(stderr, "config init: %v\n", err)
+		return 1
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			fmt.Fprintf(stderr, "config init: %s already exists\n", path)
+		} else {
+			fmt.Fprintf(stderr, "config init: %v\n", err)
+		}
+		return 1
+	}
+	if _, err := f.Write(exampleConfig); err != nil {
+		f.Close()
+		fmt.Fprintf(stderr, "config init: %v\n", err)
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(stderr, "config init: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "wrote %s\n", path)
+	return 0
+}
+
+// runConfigValidate loads every file and lists all of its problems. The
+// exit code is 1 if any file is invalid.
+func runConfigValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:\n  %s config validate [file ...]\n\nWithout files the default config file is checked.\n", strings.ToLower(appName))
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{defaultConfigPath()}
+	}
+
+	code := 0
+	for _, path := range paths {
+		problems := configProblems(path)
+		if len(problems) == 0 {
+			fmt.Fprintf(stdout, "%s: ok\n", path)
+			continue
+		}
+		code = 1
+		fmt.Fprintf(stderr, "%s: %d problem(s)\n", path, len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(stderr, "  %v\n", p)
+		}
+	}
+	return code
+}
+
+// configProblems lists what is wrong with the config file at path.
+func configProblems(path string) []error {
+	return config.Problems(config.Check(path))
+}
+
+func runConfigShow(args []string, stdout, stderr io.Writer) int {
+	path, code, ok := configFlags("show", args, stderr)
+	if !ok {
+		return code
+	}
+	settings, err := config.Explain(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "config show: %v\n", err)
+		return 1
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	// Keep code snippets readable.
+	enc.SetEscapeHTML(false)
+	out := struct {
+		Path     string                    `json:"path"`
+		Settings map[string]config.Setting `json:"settings"`
+	}{path, settings}
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(stderr, "config show: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tuitype/internal/config"
+)
+
+func TestConfigInitDoesNotClobber(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuiper", "config.json")
+	var stdout, stderr bytes.Buffer
+	if code := runConfig([]string{"init", "-config", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("init exit = %d, stderr = %s", code, stderr.String())
+	}
+	if _, err := config.Load(path); err != nil {
+		t.Fatalf("the initial config does not load: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"seed": 1}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	stderr.Reset()
+	if code := runConfig([]string{"init", "-config", path}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "already exists") {
+		t.Fatalf("second init exit = %d, stderr = %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"seed": 1}` {
+		t.Fatalf("init overwrote the config: %s", data)
+	}
+}
+
+func TestConfigValidateReportsEveryProblem(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(good, []byte(`{"durations": ["45s"]}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	data := `{
+		"durations": ["soon", "1m"],
+		"prompt_word_count": -1,
+		"promt_word_count": 30,
+		"theme": "neon",
+		"themes": {"gruvbox": {}, "mine": {"base": "nope"}},
+		"keybindings": {"jump": ["x"], "quit": []}
+	}`
+	if err := os.WriteFile(bad, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runConfig([]string{"validate", good, bad, filepath.Join(dir, "missing.json")}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("validate exit = %d, want 1", code)
+	}
+	if !strings.Contains(stdout.String(), good+": ok") {
+		t.Fatalf("stdout = %q, want the good file reported ok", stdout.String())
+	}
+	out := stderr.String()
+	for _, want := range []string{
+		bad + ": 8 problem(s)",
+		`invalid duration "soon"`,
+		"prompt_word_count must be > 0",
+		`unknown key "promt_word_count"`,
+		`unknown theme "neon"`,
+		`theme "gruvbox" is built in`,
+		`unknown base theme "nope"`,
+		`unknown action "jump"`,
+		"quit: no keys",
+		"missing.json",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("stderr lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestConfigShowSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"durations": ["45s"], "go_examples": ["x := 1"]}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("NO_COLOR", "")
+	var stdout, stderr bytes.Buffer
+	if code := runConfig([]string{"show", "-config", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("show exit = %d, stderr = %s", code, stderr.String())
+	}
+	var got struct {
+		Path     string `json:"path"`
+		Settings map[string]struct {
+			Value  json.RawMessage `json:"value"`
+			Source string          `json:"source"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, stdout.String())
+	}
+	checks := []struct{ key, source, value string }{
+		{"durations", config.SourceFile, `["45s"]`},
+		{"prompt_word_count", config.SourceDefault, "18"},
+		{"code_examples", config.SourceFile, ""},
+		{"theme", config.SourceDefault, `"name":"catppuccin"`},
+		{"keybindings", config.SourceDefault, `"restart-test":["ctrl+r"]`},
+	}
+	for _, c := range checks {
+		s, ok := got.Settings[c.key]
+		var value bytes.Buffer
+		json.Compact(&value, s.Value)
+		if !ok || s.Source != c.source || !strings.Contains(value.String(), c.value) {
+			t.Fatalf("%s = %s from %q, want %s from %q", c.key, value.String(), s.Source, c.value, c.source)
+		}
+	}
+	if got.Path != path || len(got.Settings) != 13 {
+		t.Fatalf("path %q with %d settings", got.Path, len(got.Settings))
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tuitype/internal/atomicfile"
+	"tuitype/internal/engine"
+	"tuitype/internal/prompt"
+)
+
+// maxTextSize caps the custom text read from a file or stdin.
+const maxTextSize = 8 << 20
+
+// customText is the text loaded with -file or from stdin for the custom
+// text mode.
+type customText struct {
+	name   string
+	hash   string
+	chunks []string
+	// next is the chunk the next session starts at.
+	next int
+}
+
+// readCustomText reads r and splits it into prompts of at most words
+// words. The hash identifies the text across runs, whatever it is called.
+func readCustomText(r io.Reader, name string, words int) (*customText, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, maxTextSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	if len(raw) > maxTextSize {
+		return nil, fmt.Errorf("%s is larger than %d MiB", name, maxTextSize>>20)
+	}
+	chunks := prompt.SplitText(string(raw), words)
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("%s has no text to type", name)
+	}
+	sum := sha256.Sum256(raw)
+	return &customText{name: name, hash: hex.EncodeToString(sum[:8]), chunks: chunks}, nil
+}
+
+// openCustomText reads the text of path, or of stdin if path is "-".
+func openCustomText(path string, words int) (*customText, error) {
+	if path == "-" {
+		return readCustomText(os.Stdin, "stdin", words)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readCustomText(f, filepath.Base(path), words)
+}
+
+// completed counts the chunks of a session that were typed to the end. A
+// final chunk cut short by a word limit does not count.
+func (t *customText) completed(snap engine.Snapshot) int {
+	n := len(snap.Prompts) - 1
+	last := t.chunks[(t.next+n)%len(t.chunks)]
+	if len(snap.Input) > 0 && len(snap.Input) == len([]rune(snap.Prompt)) && snap.Prompt == last {
+		n++
+	}
+	return n
+}
+
+// textPosition is how far through a custom text the typist got.
+type textPosition struct {
+	Name      string    `json:"name"`
+	Chunk     int       `json:"chunk"`
+	Chunks    int       `json:"chunks"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// textProgressPath is where custom text positions are kept, next to the
+// history file.
+func textProgressPath(historyPath string) string {
+	return filepath.Join(filepath.Dir(historyPath), "text-progress.json")
+}
+
+// loadTextProgress reads the saved positions by text hash. A missing file
+// is not an error.
+func loadTextProgress(path string) (map[string]textPosition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]textPosition{}, nil
+		}
+		return nil, fmt.Errorf("read text progress %s: %w", path, err)
+	}
+	progress := map[string]textPosition{}
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("decode text progress %s: %w", path, err)
+	}
+	return progress, nil
+}
+
+// saveTextPosition records pos for the text with the given hash, keeping
+// the positions of other texts. The file is replaced atomically.
+func saveTextPosition(path, hash string, pos textPosition) error {
+	progress, err := loadTextProgress(path)
+	if err != nil {
+		return err
+	}
+	progress[hash] = pos
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode text progress: %w", err)
+	}
+	if err := atomicfile.Write(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("write text progress: %w", err)
+	}
+	return nil
+}
+
+// resume moves t to its saved position. A text split into a different
+// number of chunks, e.g. after prompt_word_count changed, resumes at the
+// same fraction of the way through.
+func (t *customText) resume(progress map[string]textPosition) {
+	pos, ok := progress[t.hash]
+	if !ok || pos.Chunks <= 0 {
+		return
+	}
+	next := pos.Chunk
+	if pos.Chunks != len(t.chunks) {
+		next = pos.Chunk * len(t.chunks) / pos.Chunks
+	}
+	t.next = min(max(next, 0), len(t.chunks)-1)
+}
+
+// textSavedMsg reports the outcome of saving the custom text position.
+type textSavedMsg struct {
+	err error
+}
+
+// useText loads t into the custom text mode, resumes it from the progress
+// file if there is one and preselects the mode.
+func (m *model) useText(t *customText, progressPath string) error {
+	m.text = t
+	m.textProgress = progressPath
+	m.prompts.SetText(t.chunks)
+	m.selectedMode = prompt.ModeText
+	if progressPath == "" {
+		return nil
+	}
+	progress, err := loadTextProgress(progressPath)
+	if err != nil {
+		return err
+	}
+	t.resume(progress)
+	return nil
+}
+
+// advanceText moves the custom text past the chunks completed in the
+// current session and saves the new position.
+func (m *model) advanceText(at time.Time) tea.Cmd {
+	t := m.text
+	t.next = (t.next + t.completed(m.session.Snapshot())) % len(t.chunks)
+	if m.textProgress == "" {
+		return nil
+	}
+	path, hash := m.textProgress, t.hash
+	pos := textPosition{Name: t.name, Chunk: t.next, Chunks: len(t.chunks), UpdatedAt: at}
+	return func() tea.Msg {
+		return textSavedMsg{err: saveTextPosition(path, hash, pos)}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tuitype/internal/config"
+	"tuitype/internal/history"
+	"tuitype/internal/prompt"
+)
+
+func TestReadCustomText(t *testing.T) {
+	a, err := readCustomText(strings.NewReader("One two. Three four."), "a.txt", 2)
+	if err != nil {
+		t.Fatalf("readCustomText returned error: %v", err)
+	}
+	if len(a.chunks) != 2 || a.chunks[1] != "Three four." {
+		t.Fatalf("chunks = %q", a.chunks)
+	}
+	b, err := readCustomText(strings.NewReader("One two. Three four."), "b.txt", 4)
+	if err != nil {
+		t.Fatalf("readCustomText returned error: %v", err)
+	}
+	if a.hash != b.hash || a.hash == "" {
+		t.Fatalf("hashes %q and %q, want the same text to hash the same", a.hash, b.hash)
+	}
+	if _, err := readCustomText(strings.NewReader("\n\n"), "empty.txt", 4); err == nil {
+		t.Fatal("expected an error for a text without words")
+	}
+}
+
+func TestCustomTextResumesAcrossSessions(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	dir := t.TempDir()
+	progressPath := textProgressPath(filepath.Join(dir, "history.jsonl"))
+	text := "ab. cd. ef."
+	start := func() model {
+		ct, err := readCustomText(strings.NewReader(text), "notes.txt", 1)
+		if err != nil {
+			t.Fatalf("readCustomText returned error: %v", err)
+		}
+		m := initialModel(cfg, nil)
+		if err := m.useText(ct, progressPath); err != nil {
+			t.Fatalf("useText returned error: %v", err)
+		}
+		if m.selectedMode != prompt.ModeText {
+			t.Fatalf("selected mode = %v, want the custom text mode", m.selectedMode)
+		}
+		m.length = testLength{kind: history.KindPrompt}
+		m.resetSession()
+		return m
+	}
+
+	m := start()
+	if got := m.session.Snapshot().Prompt; got != "ab." {
+		t.Fatalf("first prompt = %q, want %q", got, "ab.")
+	}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, r := range "ab." {
+		m.session.Type(r, at.Add(time.Duration(i)*100*time.Millisecond))
+	}
+	cmd := m.finish(m.session.Snapshot().FinishedAt)
+	if m.text.next != 1 {
+		t.Fatalf("next chunk = %d, want 1", m.text.next)
+	}
+	// Without a history store the only command is the progress save.
+	if msg, ok := cmd().(textSavedMsg); !ok || msg.err != nil {
+		t.Fatalf("finish command returned %#v, want a saved position", msg)
+	}
+
+	m = start()
+	if got := m.session.Snapshot().Prompt; got != "cd." {
+		t.Fatalf("resumed prompt = %q, want %q", got, "cd.")
+	}
+
+	// A session that ends mid-chunk resumes at the same chunk.
+	m.session.Type('c', at)
+	m.length = timeLength(time.Second)
+	m.finish(at.Add(time.Second))
+	if m.text.next != 1 {
+		t.Fatalf("next chunk after a partial chunk = %d, want 1", m.text.next)
+	}
+}
+
+func TestCustomTextResumesAtSameFraction(t *testing.T) {
+	ct := &customText{hash: "h", chunks: make([]string, 10)}
+	ct.resume(map[string]textPosition{"h": {Chunk: 2, Chunks: 4}})
+	if ct.next != 5 {
+		t.Fatalf("next = %d, want 5", ct.next)
+	}
+	ct.resume(map[string]textPosition{"h": {Chunk: 40, Chunks: 40}})
+	if ct.next != 9 {
+		t.Fatalf("next = %d, want the last chunk", ct.next)
+	}
+}
+
+func TestCustomTextNeedsText(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	m := initialModel(cfg, nil)
+	m.screens = []screen{screenModes}
+	m.width, m.height = 100, 30
+	tm, _ := m.Update(keyMsg("6"))
+	tm, _ = tm.Update(keyMsg("enter"))
+	m = tm.(model)
+	if m.selectedMode != prompt.ModeText || m.screen() != screenModes {
+		t.Fatalf("mode %v, screen %v; want to stay on the mode menu", m.selectedMode, m.screen())
+	}
+	if view := m.View(); !strings.Contains(view, "no text loaded") {
+		t.Fatalf("mode menu lacks the hint:\n%s", view)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"tuitype/internal/engine"
+	"tuitype/internal/history"
+	"tuitype/internal/metrics"
+)
+
+// ghost races a recorded session alongside the live one. Its session is
+// fed the recorded keystrokes up to the live elapsed time.
+type ghost struct {
+	rec     history.Session
+	player  *replayer
+	session *engine.Session
+}
+
+func newGhost(rec history.Session, opts engine.Options) *ghost {
+	player := newReplayer(rec, opts)
+	return &ghost{rec: rec, player: player, session: player.newSession()}
+}
+
+func (g *ghost) advance(elapsed time.Duration) {
+	g.player.seek(elapsed, g.session)
+}
+
+// caret returns the ghost's cursor in the live prompt, if the ghost is on
+// the same prompt.
+func (g *ghost) caret(live engine.Snapshot) (int, bool) {
+	snap := g.session.Snapshot()
+	if len(snap.Prompts) != len(live.Prompts) || snap.Prompt != live.Prompt {
+		return 0, false
+	}
+	return len(snap.Input), true
+}
+
+// delta formats the live lead over the ghost in correct characters and WPM.
+func (g *ghost) delta(live *engine.Session, elapsed time.Duration) string {
+	chars := live.Snapshot().Correct - g.session.Snapshot().Correct
+	wpm := int(math.Round(metrics.Compute(live.Events(), elapsed).NetWPM - metrics.Compute(g.session.Events(), elapsed).NetWPM))
+	return fmt.Sprintf("ghost %+d chars %+d wpm", chars, wpm)
+}
+
+// bestGhost picks the fastest replayable session of the same mode, code
+// language and length, and of the same seed unless seed is zero. Sessions
+// without a seed cannot be raced on identical prompts.
+func bestGhost(sessions []history.Session, mode, language string, length testLength, seed int64) (history.Session, bool) {
+	var best history.Session
+	found := false
+	for _, s := range sessions {
+		if s.Mode != mode || s.Language != language || lengthOf(s) != length || s.Seed == 0 || len(s.Keystrokes) == 0 {
+			continue
+		}
+		if seed != 0 && s.Seed != seed {
+			continue
+		}
+		if !found || s.WPM > best.WPM {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tuitype/internal/config"
+	"tuitype/internal/engine"
+	"tuitype/internal/history"
+)
+
+func TestBestGhostPicksFastestReplayableRun(t *testing.T) {
+	keys := []engine.Event{{Rune: 'a', Expected: 'a'}}
+	sessions := []history.Session{
+		{ID: "slow", Mode: "normal", Duration: 30 * time.Second, WPM: 40, Seed: 1, Keystrokes: keys},
+		{ID: "fast", Mode: "normal", Duration: 30 * time.Second, WPM: 70, Seed: 2, Keystrokes: keys},
+		{ID: "unseeded", Mode: "normal", Duration: 30 * time.Second, WPM: 90, Keystrokes: keys},
+		{ID: "other-duration", Mode: "normal", Duration: time.Minute, WPM: 95, Seed: 3, Keystrokes: keys},
+		{ID: "python", Mode: "code", Language: "python", Duration: 30 * time.Second, WPM: 50, Seed: 5, Keystrokes: keys},
+		{ID: "words", Mode: "normal", Kind: history.KindWords, Words: 25, Duration: 30 * time.Second, WPM: 99, Seed: 4, Keystrokes: keys},
+	}
+	thirty := timeLength(30 * time.Second)
+	got, ok := bestGhost(sessions, "normal", "", thirty, 0)
+	if !ok || got.ID != "fast" {
+		t.Fatalf("bestGhost = %q, %v, want fast", got.ID, ok)
+	}
+	if got, ok := bestGhost(sessions, "normal", "", thirty, 1); !ok || got.ID != "slow" {
+		t.Fatalf("bestGhost(seed 1) = %q, %v, want slow", got.ID, ok)
+	}
+	if _, ok := bestGhost(sessions, "code", "go", thirty, 0); ok {
+		t.Fatal("a Go run must not race a Python ghost")
+	}
+	if got, ok := bestGhost(sessions, "code", "python", thirty, 0); !ok || got.ID != "python" {
+		t.Fatalf("bestGhost(python) = %q, %v, want python", got.ID, ok)
+	}
+	words := testLength{kind: history.KindWords, words: 25}
+	if got, ok := bestGhost(sessions, "normal", "", words, 0); !ok || got.ID != "words" {
+		t.Fatalf("bestGhost(25 words) = %q, %v, want words", got.ID, ok)
+	}
+}
+
+func TestGhostCaretAndDelta(t *testing.T) {
+	rec := recordedSession(t)
+	g := newGhost(rec, engine.Options{})
+	g.advance(2500 * time.Millisecond)
+
+	live := engine.New("ab", nil, engine.Options{})
+	live.Type('a', rec.StartedAt)
+	snap := live.Snapshot()
+	if at, ok := g.caret(snap); !ok || at != 2 {
+		t.Fatalf("caret = %d, %v, want 2, true", at, ok)
+	}
+	if got := g.delta(live, time.Minute); got != "ghost -1 chars +0 wpm" {
+		t.Fatalf("delta = %q", got)
+	}
+
+	other := engine.New("zz", nil, engine.Options{})
+	if _, ok := g.caret(other.Snapshot()); ok {
+		t.Fatal("ghost caret shown on a different prompt")
+	}
+}
+
+func TestResetSessionReusesGhostSeed(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	store := history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+
+	first := initialModel(cfg, store)
+	first.resetSession()
+	prompt := first.session.Snapshot().Prompt
+	rec := recordedSession(t)
+	rec.Prompts = []string{prompt}
+	rec.Duration = first.length.duration
+	rec.Seed = first.seed
+	if err := store.Append(rec); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+
+	m := initialModel(cfg, store)
+	m.useGhost = true
+	m.resetSession()
+	if m.ghost == nil {
+		t.Fatal("expected a ghost from history")
+	}
+	if m.seed != rec.Seed || m.session.Snapshot().Prompt != prompt {
+		t.Fatalf("seed/prompt = %d %q, want %d %q", m.seed, m.session.Snapshot().Prompt, rec.Seed, prompt)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"tuitype/internal/history"
+	"tuitype/internal/metrics"
+)
+
+// keyboardRows is a US QWERTY layout: every physical key as its unshifted
+// and shifted character.
+var keyboardRows = [][][2]rune{
+	keyRow("`1234567890-=", "~!@#$%^&*()_+"),
+	keyRow("qwertyuiop[]\\", "QWERTYUIOP{}|"),
+	keyRow("asdfghjkl;'", "ASDFGHJKL:\""),
+	keyRow("zxcvbnm,./", "ZXCVBNM<>?"),
+	{{' ', 0}},
+}
+
+// keyboardIndent is the offset of each row in key cells, in half cells.
+var keyboardIndent = []int{0, 3, 4, 5, 14}
+
+func keyRow(plain, shifted string) [][2]rune {
+	p, s := []rune(plain), []rune(shifted)
+	row := make([][2]rune, len(p))
+	for i := range p {
+		row[i] = [2]rune{p[i], s[i]}
+	}
+	return row
+}
+
+// heatMetric selects what the heatmap colors keys by.
+type heatMetric int
+
+const (
+	heatErrors heatMetric = iota
+	heatLatency
+)
+
+func (h heatMetric) String() string {
+	if h == heatLatency {
+		return "latency"
+	}
+	return "error rate"
+}
+
+// heatmapMinSamples keeps keys and bigrams typed only once or twice out of
+// the rankings.
+const heatmapMinSamples = 3
+
+// physicalStat merges the stats of both characters on a key.
+func physicalStat(report *metrics.KeyReport, key [2]rune) metrics.KeyStat {
+	st := report.Key(key[0])
+	if key[1] != 0 {
+		sh := report.Key(key[1])
+		st.Presses += sh.Presses
+		st.Errors += sh.Errors
+		st.Timed += sh.Timed
+		st.Latency += sh.Latency
+	}
+	return st
+}
+
+// keyboardHeat scores every physical key from 0 (best) to 1 (worst) by
+// metric. Keys without data are left out. Error rates saturate at 20%;
+// latencies are scaled between the fastest and slowest key.
+func keyboardHeat(report *metrics.KeyReport, metric heatMetric) map[[2]rune]float64 {
+	heat := map[[2]rune]float64{}
+	var lo, hi time.Duration
+	first := true
+	for _, row := range keyboardRows {
+		for _, key := range row {
+			st := physicalStat(report, key)
+			if metric == heatLatency && st.Timed > 0 {
+				avg := st.AvgLatency()
+				if first || avg < lo {
+					lo = avg
+				}
+				if first || avg > hi {
+					hi = avg
+				}
+				first = false
+			}
+		}
+	}
+	for _, row := range keyboardRows {
+		for _, key := range row {
+			st := physicalStat(report, key)
+			switch {
+			case metric == heatErrors && st.Presses > 0:
+				heat[key] = min(st.ErrorRate()/20, 1)
+			case metric == heatLatency && st.Timed > 0:
+				if hi > lo {
+					heat[key] = float64(st.AvgLatency()-lo) / float64(hi-lo)
+				} else {
+					heat[key] = 0
+				}
+			}
+		}
+	}
+	return heat
+}
+
+// heatLevel buckets a heat score into one of n levels.
+func heatLevel(h float64, n int) int {
+	return min(int(h*float64(n)), n-1)
+}
+
+func keyLabel(key [2]rune) string {
+	if key[0] == ' ' {
+		return "space"
+	}
+	return string(key[0])
+}
+
+// loadKeyReport aggregates the keystroke logs in the history file, limited
+// to one mode unless mode is empty.
+func loadKeyReport(path, mode string) (*metrics.KeyReport, int, error) {
+	sessions, err := history.NewStore(path).Load()
+	if err != nil {
+		return nil, 0, err
+	}
+	report := &metrics.KeyReport{}
+	used := 0
+	for _, s := range sessions {
+		if (mode != "" && s.Mode != mode) || len(s.Keystrokes) == 0 {
+			continue
+		}
+		report.Add(s.Keystrokes)
+		used++
+	}
+	return report, used, nil
+}
+
+// renderHeatmap draws the keyboard with every key colored by heat; levels
+// holds the styles from best to worst and empty styles keys without data.
+func renderHeatmap(heat map[[2]rune]float64, levels []lipgloss.Style, empty lipgloss.Style) string {
+	lines := make([]string, 0, len(keyboardRows))
+	for i, row := range keyboardRows {
+		var b strings.Builder
+		b.WriteString(strings.Repeat(" ", keyboardIndent[i]))
+		for _, key := range row {
+			style := empty
+			if h, ok := heat[key]; ok {
+				style = levels[heatLevel(h, len(levels))]
+			}
+			label := " " + keyLabel(key) + " "
+			if key[0] == ' ' {
+				label = "    space    "
+			}
+			b.WriteString(style.Render(label))
+		}
+		lines = append(lines, b.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// heatShades are the plain-text heat levels, best to worst.
+var heatShades = []rune("░▒▓█")
+
+// writeHeatmapText prints the keyboard as key/shade pairs, with a dot for
+// keys without data.
+func writeHeatmapText(out io.Writer, heat map[[2]rune]float64) {
+	for i, row := range keyboardRows {
+		fmt.Fprint(out, strings.Repeat(" ", keyboardIndent[i]))
+		for _, key := range row {
+			shade := '·'
+			if h, ok := heat[key]; ok {
+				shade = heatShades[heatLevel(h, len(heatShades))]
+			}
+			fmt.Fprintf(out, "%s%c ", keyLabel(key), shade)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// weakKeysLine lists the worst keys by metric as exact characters, e.g.
+// "{ 18% 420ms".
+func weakKeysLine(report *metrics.KeyReport, metric heatMetric, n int) string {
+	keys := report.WorstKeys(heatmapMinSamples)
+	if metric == heatLatency {
+		keys = report.SlowestKeys(heatmapMinSamples)
+	}
+	parts := make([]string, 0, n)
+	for _, k := range keys {
+		if len(parts) == n {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %.0f%% %dms", keyName(k.Key), k.ErrorRate(), k.AvgLatency().Milliseconds()))
+	}
+	if len(parts) == 0 {
+		return "not enough data yet"
+	}
+	return strings.Join(parts, " • ")
+}
+
+func slowBigramsLine(report *metrics.KeyReport, n int) string {
+	bigrams := report.SlowestBigrams(heatmapMinSamples)
+	parts := make([]string, 0, n)
+	for _, b := range bigrams {
+		if len(parts) == n {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%q %dms", b.Bigram.String(), b.Avg().Milliseconds()))
+	}
+	if len(parts) == 0 {
+		return "not enough data yet"
+	}
+	return strings.Join(parts, " • ")
+}
+
+func runHeatmap(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	historyPath := fs.String("history", defaultHistoryPath(), "path to session history file")
+	mode := fs.String("mode", "", "only include this mode (normal, special, quote, code, weak, text)")
+	metric := fs.String("by", "errors", "color the keyboard by errors or latency")
+	top := fs.Int("top", 10, "number of keys and bigrams to list")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:\n  %s heatmap [options]\n\nOptions:\n", strings.ToLower(appName))
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	by := heatErrors
+	switch *metric {
+	case "errors":
+	case "latency":
+		by = heatLatency
+	default:
+		fmt.Fprintf(stderr, "heatmap: -by must be errors or latency, not %q\n", *metric)
+		return 2
+	}
+	if *top <= 0 {
+		fmt.Fprintln(stderr, "heatmap: -top must be > 0")
+		return 2
+	}
+
+	report, used, err := loadKeyReport(*historyPath, *mode)
+	if err != nil {
+		fmt.Fprintf(stderr, "heatmap: %v\n", err)
+		return 1
+	}
+	if used == 0 {
+		fmt.Fprintln(stdout, "no keystroke logs recorded yet")
+		return 0
+	}
+
+	fmt.Fprintf(stdout, "%s across %d sessions (%s low to high, · no data)\n\n", by, used, string(heatShades))
+	writeHeatmapText(stdout, keyboardHeat(report, by))
+
+	keys := report.WorstKeys(heatmapMinSamples)
+	if by == heatLatency {
+		keys = report.SlowestKeys(heatmapMinSamples)
+	}
+	fmt.Fprintln(stdout)
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tPRESSES\tERRORS\tERR%\tAVG MS")
+	for i, k := range keys {
+		if i == *top {
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%d\n", keyName(k.Key), k.Presses, k.Errors, k.ErrorRate(), k.AvgLatency().Milliseconds())
+	}
+	tw.Flush()
+
+	fmt.Fprintln(stdout)
+	tw = tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BIGRAM\tSAMPLES\tAVG MS")
+	for i, b := range report.SlowestBigrams(heatmapMinSamples) {
+		if i == *top {
+			break
+		}
+		fmt.Fprintf(tw, "%q\t%d\t%d\n", b.Bigram.String(), b.Samples, b.Avg().Milliseconds())
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tuitype/internal/engine"
+	"tuitype/internal/history"
+	"tuitype/internal/metrics"
+)
+
+// slowBraceTyping types every '{' six times slower than 'a' and misses the
+// final '|'.
+func slowBraceTyping() *engine.Session {
+	s := engine.New("a{a{a{a|", nil, engine.Options{})
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, r := range "a{a{a{a]" {
+		if r == '{' {
+			at = at.Add(600 * time.Millisecond)
+		} else {
+			at = at.Add(100 * time.Millisecond)
+		}
+		s.Type(r, at)
+	}
+	return s
+}
+
+func slowBraceSession() history.Session {
+	s := slowBraceTyping()
+	return history.Session{ID: "braces", Mode: "special", StartedAt: s.Snapshot().StartedAt, Keystrokes: s.Events()}
+}
+
+func TestKeyboardHeat(t *testing.T) {
+	var report metrics.KeyReport
+	report.Add(slowBraceSession().Keystrokes)
+
+	errors := keyboardHeat(&report, heatErrors)
+	if errors[[2]rune{'\\', '|'}] != 1 || errors[[2]rune{'[', '{'}] != 0 {
+		t.Fatalf("error heat = %v, want | hot and { cold", errors)
+	}
+	if _, ok := errors[[2]rune{'q', 'Q'}]; ok {
+		t.Fatal("untyped key has heat")
+	}
+	latency := keyboardHeat(&report, heatLatency)
+	if latency[[2]rune{'[', '{'}] != 1 || latency[[2]rune{'a', 'A'}] != 0 {
+		t.Fatalf("latency heat = %v, want { slowest and a fastest", latency)
+	}
+}
+
+func TestRunHeatmap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := history.NewStore(path)
+	if err := store.Append(slowBraceSession()); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	if err := store.Append(history.Session{ID: "old", Mode: "normal"}); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runHeatmap([]string{"-history", path, "-by", "latency"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runHeatmap exit = %d, stderr = %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "latency across 1 sessions") || !strings.Contains(out, "[█") {
+		t.Fatalf("heatmap missing slow [{ key:\n%s", out)
+	}
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "KEY") {
+			if !strings.HasPrefix(lines[i+1], "'{'") {
+				t.Fatalf("slowest key row = %q, want '{'", lines[i+1])
+			}
+		}
+	}
+	if !strings.Contains(out, `"a{"`) {
+		t.Fatalf("bigram table missing a{:\n%s", out)
+	}
+
+	stdout.Reset()
+	if code := runHeatmap([]string{"-history", path, "-mode", "quote"}, &stdout, &stderr); code != 0 || !strings.Contains(stdout.String(), "no keystroke logs") {
+		t.Fatalf("runHeatmap -mode quote = %d, %q", code, stdout.String())
+	}
+	if code := runHeatmap([]string{"-history", path, "-by", "speed"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("runHeatmap -by speed exit = %d, want 2", code)
+	}
+}
+
+func TestHeatmapReachableFromModeMenu(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := history.NewStore(path)
+	if err := store.Append(slowBraceSession()); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	m := model{history: store, screens: []screen{screenModes}}
+	next, _ := m.Update(keyMsg("h"))
+	m = next.(model)
+	if m.screen() != screenHeatmap || m.heatErr != nil || m.heatSessions != 1 {
+		t.Fatalf("heatmap state = %v %v %d", m.screen(), m.heatErr, m.heatSessions)
+	}
+	next, _ = m.Update(keyMsg("esc"))
+	if m = next.(model); m.screen() != screenModes {
+		t.Fatal("esc should return to the mode menu")
+	}
+}
@@ -2,10 +2,15 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
+
+	"tuitype/internal/keymap"
+	"tuitype/internal/theme"
 )
 
 var defaultWords = []string{
@@ -21,25 +26,85 @@ var defaultSpecialCharWords = []string{
 	"==!=", "++--", "<<>>", "||&&", "@@##", "$$%%", "^^~~", ".,<>", "///\\", "(()))",
 }
 
+// CodeLanguages are the languages of Code Practice, in menu order. The
+// prompt package has a sanitizer for each.
+var CodeLanguages = []string{"go", "python", "javascript", "typescript", "rust", "sql", "shell"}
+
+var defaultCodeExamples = map[string][]string{
+	"python": {
+		"for i, name in enumerate(names):\n    print(f\"{i}: {name}\")",
+		"def area(w, h=1):\n    return w * h",
+		"with open(path) as f:\n    lines = [l.strip() for l in f if l]",
+	},
+	"javascript": {
+		"const total = items.reduce((sum, x) => sum + x.price, 0);",
+		"function greet(name) {\n  return `hello, ${name}!`;\n}",
+		"fetch(url)\n  .then((res) => res.json())\n  .catch(console.error);",
+	},
+	"typescript": {
+		"interface User {\n  id: number;\n  name?: string;\n}",
+		"const ids: number[] = users.map((u) => u.id);",
+		"function first<T>(xs: T[]): T | undefined {\n  return xs[0];\n}",
+	},
+	"rust": {
+		"let v: Vec<i32> = (1..=5).map(|x| x * x).collect();",
+		"fn parse(s: &str) -> Result<u32, ParseIntError> {\n    s.trim().parse::<u32>()\n}",
+		"match opt {\n    Some(x) if x > 0 => println!(\"{x}\"),\n    _ => {}\n}",
+	},
+	"sql": {
+		"SELECT id, name FROM users WHERE age >= 18 ORDER BY name;",
+		"UPDATE orders SET status = 'sent'\nWHERE id IN (SELECT order_id FROM shipments);",
+		"SELECT team, COUNT(*) AS n\nFROM players\nGROUP BY team\nHAVING COUNT(*) > 3;",
+	},
+	"shell": {
+		"for f in *.log; do gzip \"$f\"; done",
+		"if [ -z \"$HOME\" ]; then\n  echo \"no home\" >&2\n  exit 1\nfi",
+		"grep -rn 'TODO' src/ | awk -F: '{print $1}' | sort -u",
+	},
+}
+
 type AppConfig struct {
 	NormalWords       []string `json:"normal_words"`
 	SpecialCharWords  []string `json:"special_char_words"`
 	Durations         []string `json:"durations"`
+	WordCounts        []int    `json:"word_counts"`
 	PromptWordCount   int      `json:"prompt_word_count"`
 	QuoteEndpoint     string   `json:"quote_endpoint"`
 	GoExampleEndpoint string   `json:"go_example_endpoint"`
 	GoExamples        []string `json:"go_examples"`
+	// CodeExamples and CodeEndpoints are keyed by language. The go_* keys
+	// above are used for Go when these have no "go" entry.
+	CodeExamples  map[string][]string `json:"code_examples"`
+	CodeEndpoints map[string]string   `json:"code_endpoints"`
+	// CodeDir is a source tree whose Go code replaces the Go examples.
+	CodeDir    string `json:"code_dir"`
+	Seed       int64  `json:"seed"`
+	SkipIndent bool   `json:"skip_indent"`
+	// Theme names a built-in theme or one of Themes.
+	Theme  string                  `json:"theme"`
+	Themes map[string]theme.Custom `json:"themes"`
+	// Keybindings replaces the keys of actions, e.g. "menu-prev": ["k"].
+	Keybindings map[string][]string `json:"keybindings"`
 }
 
 type RuntimeConfig struct {
-	Words             []string
-	SpecialCharWords  []string
-	DurationOptions   []time.Duration
-	DurationLabels    []string
-	PromptWordCount   int
-	QuoteEndpoint     string
-	GoExampleEndpoint string
-	GoExamples        []string
+	Words            []string
+	SpecialCharWords []string
+	DurationOptions  []time.Duration
+	DurationLabels   []string
+	WordCounts       []int
+	PromptWordCount  int
+	QuoteEndpoint    string
+	// CodeExamples has snippets for every language in CodeLanguages;
+	// CodeEndpoints only the languages with a remote endpoint.
+	CodeExamples  map[string][]string
+	CodeEndpoints map[string]string
+	CodeDir       string
+	Seed          int64
+	// SkipIndent fills in the indentation of multi-line prompts.
+	SkipIndent bool
+	Theme      theme.Theme
+	Keys       keymap.KeyMap
 }
 
 func Default() AppConfig {
@@ -47,6 +112,7 @@ func Default() AppConfig {
 		NormalWords:       append([]string(nil), defaultWords...),
 		SpecialCharWords:  append([]string(nil), defaultSpecialCharWords...),
 		Durations:         []string{"15s", "30s", "1m", "2m"},
+		WordCounts:        []int{10, 25, 50, 100},
 		PromptWordCount:   18,
 		QuoteEndpoint:     "https://dummyjson.com/quotes/random",
 		GoExampleEndpoint: "",
@@ -55,21 +121,25 @@ func Default() AppConfig {
 			"if err != nil { return fmt.Errorf(\"failed: %w\", err) }",
 			"items := []string{\"go\", \"tui\"}; for _, it := range items { fmt.Println(it) }",
 		},
+		CodeExamples:  copyExamples(defaultCodeExamples),
+		CodeEndpoints: map[string]string{},
 	}
 }
 
+// Resolve validates cfg and derives the runtime config from it.
 func Resolve(cfg AppConfig) (RuntimeConfig, error) {
+	var errs []error
 	if len(cfg.NormalWords) == 0 {
-		return RuntimeConfig{}, fmt.Errorf("normal_words must not be empty")
+		errs = append(errs, fmt.Errorf("normal_words must not be empty"))
 	}
 	if len(cfg.SpecialCharWords) == 0 {
-		return RuntimeConfig{}, fmt.Errorf("special_char_words must not be empty")
+		errs = append(errs, fmt.Errorf("special_char_words must not be empty"))
 	}
 	if cfg.PromptWordCount <= 0 {
-		return RuntimeConfig{}, fmt.Errorf("prompt_word_count must be > 0")
+		errs = append(errs, fmt.Errorf("prompt_word_count must be > 0"))
 	}
 	if len(cfg.Durations) == 0 {
-		return RuntimeConfig{}, fmt.Errorf("durations must not be empty")
+		errs = append(errs, fmt.Errorf("durations must not be empty"))
 	}
 
 	durationOptions := make([]time.Duration, 0, len(cfg.Durations))
@@ -77,15 +147,43 @@ func Resolve(cfg AppConfig) (RuntimeConfig, error) {
 	for _, raw := range cfg.Durations {
 		d, err := time.ParseDuration(raw)
 		if err != nil {
-			return RuntimeConfig{}, fmt.Errorf("invalid duration %q: %w", raw, err)
+			errs = append(errs, fmt.Errorf("invalid duration %q: %w", raw, err))
+			continue
 		}
 		if d <= 0 {
-			return RuntimeConfig{}, fmt.Errorf("duration %q must be > 0", raw)
+			errs = append(errs, fmt.Errorf("duration %q must be > 0", raw))
+			continue
 		}
 		durationOptions = append(durationOptions, d)
 		durationLabels = append(durationLabels, raw)
 	}
 
+	for _, n := range cfg.WordCounts {
+		if n <= 0 {
+			errs = append(errs, fmt.Errorf("word count %d must be > 0", n))
+		}
+	}
+
+	var th theme.Theme
+	themeName := strings.TrimSpace(cfg.Theme)
+	customErr := theme.CheckCustom(cfg.Themes)
+	if customErr != nil {
+		errs = append(errs, prefixed("themes", customErr)...)
+	}
+	// A bad custom theme is reported above; still check that other names
+	// exist.
+	if _, custom := cfg.Themes[themeName]; customErr == nil || !custom {
+		var err error
+		if th, err = theme.Resolve(themeName, cfg.Themes); err != nil {
+			errs = append(errs, prefixed("theme", err)...)
+		}
+	}
+
+	keys, err := keymap.New(cfg.Keybindings)
+	if err != nil {
+		errs = append(errs, prefixed("keybindings", err)...)
+	}
+
 	quoteEndpoint := strings.TrimSpace(cfg.QuoteEndpoint)
 	if quoteEndpoint == "" {
 		quoteEndpoint = Default().QuoteEndpoint
@@ -99,29 +197,166 @@ func Resolve(cfg AppConfig) (RuntimeConfig, error) {
 		goExamples = append([]string(nil), Default().GoExamples...)
 	}
 
+	codeExamples := map[string][]string{}
+	codeEndpoints := map[string]string{}
+	if goExampleEndpoint != "" {
+		codeEndpoints["go"] = goExampleEndpoint
+	}
+	for lang, examples := range cfg.CodeExamples {
+		if !knownLanguage(lang) {
+			errs = append(errs, fmt.Errorf("code_examples: unknown language %q (want one of %s)", lang, strings.Join(CodeLanguages, ", ")))
+			continue
+		}
+		if len(examples) > 0 {
+			codeExamples[lang] = append([]string(nil), examples...)
+		}
+	}
+	for lang, endpoint := range cfg.CodeEndpoints {
+		if !knownLanguage(lang) {
+			errs = append(errs, fmt.Errorf("code_endpoints: unknown language %q (want one of %s)", lang, strings.Join(CodeLanguages, ", ")))
+			continue
+		}
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			codeEndpoints[lang] = endpoint
+		}
+	}
+	if len(codeExamples["go"]) == 0 {
+		codeExamples["go"] = goExamples
+	}
+	for lang, examples := range defaultCodeExamples {
+		if len(codeExamples[lang]) == 0 {
+			codeExamples[lang] = append([]string(nil), examples...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return RuntimeConfig{}, errors.Join(errs...)
+	}
 	return RuntimeConfig{
-		Words:             append([]string(nil), cfg.NormalWords...),
-		SpecialCharWords:  append([]string(nil), cfg.SpecialCharWords...),
-		DurationOptions:   durationOptions,
-		DurationLabels:    durationLabels,
-		PromptWordCount:   cfg.PromptWordCount,
-		QuoteEndpoint:     quoteEndpoint,
-		GoExampleEndpoint: goExampleEndpoint,
-		GoExamples:        goExamples,
+		Words:            append([]string(nil), cfg.NormalWords...),
+		SpecialCharWords: append([]string(nil), cfg.SpecialCharWords...),
+		DurationOptions:  durationOptions,
+		DurationLabels:   durationLabels,
+		WordCounts:       append([]int(nil), cfg.WordCounts...),
+		PromptWordCount:  cfg.PromptWordCount,
+		QuoteEndpoint:    quoteEndpoint,
+		CodeExamples:     codeExamples,
+		CodeEndpoints:    codeEndpoints,
+		CodeDir:          strings.TrimSpace(cfg.CodeDir),
+		Seed:             cfg.Seed,
+		SkipIndent:       cfg.SkipIndent,
+		Theme:            th,
+		Keys:             keys,
 	}, nil
 }
 
-func Load(path string) (RuntimeConfig, error) {
+// Problems lists the problems reported by an error of Resolve, Load or
+// Check, one per line of the error. It returns nil for a nil error.
+func Problems(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, Problems(e)...)
+	}
+	return errs
+}
+
+// prefixed prefixes each problem of err with key, so Resolve's error lists
+// every problem on its own line.
+func prefixed(key string, err error) []error {
+	errs := Problems(err)
+	out := make([]error, len(errs))
+	for i, e := range errs {
+		out[i] = fmt.Errorf("%s: %w", key, e)
+	}
+	return out
+}
+
+func knownLanguage(lang string) bool {
+	for _, l := range CodeLanguages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+func copyExamples(examples map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(examples))
+	for lang, ex := range examples {
+		out[lang] = append([]string(nil), ex...)
+	}
+	return out
+}
+
+// Read reads the config file at path over the defaults without resolving
+// it; a missing file yields the defaults.
+func Read(path string) (AppConfig, error) {
 	cfg := Default()
 	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return Resolve(cfg)
+	if err != nil && !os.IsNotExist(err) {
+		return AppConfig{}, fmt.Errorf("read config %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return AppConfig{}, fmt.Errorf("parse config %s: %w", path, err)
 		}
-		return RuntimeConfig{}, fmt.Errorf("read config %s: %w", path, err)
 	}
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return RuntimeConfig{}, fmt.Errorf("parse config %s: %w", path, err)
+	return cfg, nil
+}
+
+// Load reads and resolves the config file at path; a missing file yields
+// the defaults. A non-empty NO_COLOR environment variable selects the
+// monochrome theme whatever the file says.
+func Load(path string) (RuntimeConfig, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return RuntimeConfig{}, err
+	}
+	rc, err := Resolve(cfg)
+	if err != nil {
+		return RuntimeConfig{}, err
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		rc.Theme, _ = theme.Builtin(theme.Monochrome)
+	}
+	return rc, nil
+}
+
+// Check validates the config file at path like Load and also reports keys
+// that this version does not know, which Load ignores so that files stay
+// usable across versions. Unlike Load, a missing file is an error.
+func Check(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config %s: %w", path, err)
+	}
+	file := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	known, err := fields(AppConfig{})
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(file))
+	for key := range file {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var errs []error
+	for _, key := range keys {
+		if _, ok := known[key]; !ok {
+			errs = append(errs, fmt.Errorf("unknown key %q", key))
+		}
 	}
-	return Resolve(cfg)
+	_, err = Load(path)
+	errs = append(errs, Problems(err)...)
+	return errors.Join(errs...)
 }
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -33,6 +36,25 @@ func TestResolveInvalidDuration(t *testing.T) {
 	}
 }
 
+func TestProblemsListsEachProblem(t *testing.T) {
+	cfg := Default()
+	cfg.Durations = []string{"bad"}
+	cfg.Keybindings = map[string][]string{"nope": {"x"}, "quit": {}}
+	_, err := Resolve(cfg)
+	problems := Problems(err)
+	if len(problems) != 3 {
+		t.Fatalf("Problems = %q, want 3 problems", problems)
+	}
+	for _, p := range problems {
+		if strings.Contains(p.Error(), "\n") {
+			t.Fatalf("problem %q spans lines", p)
+		}
+	}
+	if Problems(nil) != nil {
+		t.Fatal("Problems(nil) should be nil")
+	}
+}
+
 func TestLoadMissingUsesDefaults(t *testing.T) {
 	rc, err := Load(filepath.Join(t.TempDir(), "missing.json"))
 	if err != nil {
@@ -65,3 +87,177 @@ func TestLoadFromFile(t *testing.T) {
 		t.Fatalf("DurationLabels[0] = %q, want %q", rc.DurationLabels[0], "20s")
 	}
 }
+
+func TestLoadSeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuiper.json")
+	if err := os.WriteFile(path, []byte(`{"seed": 20260105}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	rc, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if rc.Seed != 20260105 {
+		t.Fatalf("Seed = %d, want 20260105", rc.Seed)
+	}
+}
+
+func TestLoadWordCounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuiper.json")
+	if err := os.WriteFile(path, []byte(`{"word_counts": [5, 40]}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	rc, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(rc.WordCounts) != 2 || rc.WordCounts[1] != 40 {
+		t.Fatalf("WordCounts = %v, want [5 40]", rc.WordCounts)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"word_counts": [10, 0]}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for zero word count")
+	}
+}
+
+func TestLoadCodeExamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuiper.json")
+	data := `{
+		"go_examples": ["x := 1"],
+		"go_example_endpoint": "https://example.test/go",
+		"code_examples": {"python": ["print(1)"]},
+		"code_endpoints": {"rust": " https://example.test/rs "}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	rc, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := rc.CodeExamples["python"]; len(got) != 1 || got[0] != "print(1)" {
+		t.Fatalf("python examples = %q, want [print(1)]", got)
+	}
+	if got := rc.CodeExamples["go"]; len(got) != 1 || got[0] != "x := 1" {
+		t.Fatalf("go examples = %q, want go_examples", got)
+	}
+	for _, lang := range CodeLanguages {
+		if len(rc.CodeExamples[lang]) == 0 {
+			t.Fatalf("no default examples for %s", lang)
+		}
+	}
+	if rc.CodeEndpoints["go"] != "https://example.test/go" || rc.CodeEndpoints["rust"] != "https://example.test/rs" {
+		t.Fatalf("CodeEndpoints = %v", rc.CodeEndpoints)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"code_examples": {"cobol": ["MOVE A TO B."]}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for unknown language")
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuiper.json")
+	data := `{"theme": "mine", "themes": {"mine": {"base": "solarized", "pending": "#ffffff"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("NO_COLOR", "")
+	rc, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if rc.Theme.Name != "mine" || rc.Theme.Pending.Dark != "#ffffff" {
+		t.Fatalf("Theme = %+v, want mine with a white pending colour", rc.Theme)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if rc, err = Load(path); err != nil || rc.Theme.Name != "monochrome" {
+		t.Fatalf("Load with NO_COLOR = %q, %v; want the monochrome theme", rc.Theme.Name, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"theme": "neon"}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for unknown theme")
+	}
+}
+
+func TestLoadKeybindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuiper.json")
+	if err := os.WriteFile(path, []byte(`{"keybindings": {"new-test": ["ctrl+t"]}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	rc, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := rc.Keys.NewTest.Keys(); len(got) != 1 || got[0] != "ctrl+t" {
+		t.Fatalf("new-test keys = %q, want [ctrl+t]", got)
+	}
+	if got := rc.Keys.Restart.Keys(); len(got) != 1 || got[0] != "ctrl+r" {
+		t.Fatalf("restart-test keys = %q, want the default", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"keybindings": {"quit": ["q"]}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "keybindings: ") {
+		t.Fatalf("Load error = %v, want a keybindings error", err)
+	}
+}
+
+func TestSaveKeepsOtherKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuiper.json")
+	data := `{"prompt_word_count": 10, "seed": 7, "future_key": {"x": 1}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	cfg.PromptWordCount = 12
+	cfg.Durations = []string{"45s"}
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	var file map[string]json.RawMessage
+	saved, _ := os.ReadFile(path)
+	if err := json.Unmarshal(saved, &file); err != nil {
+		t.Fatalf("saved config is not JSON: %v\n%s", err, saved)
+	}
+	want := map[string]string{
+		"prompt_word_count": "12",
+		"durations":         `["45s"]`,
+		"seed":              "7",
+		"future_key":        `{"x":1}`,
+	}
+	if len(file) != len(want) {
+		t.Fatalf("saved keys = %s, want only the changed and existing keys", saved)
+	}
+	for key, value := range want {
+		var got bytes.Buffer
+		if err := json.Compact(&got, file[key]); err != nil || got.String() != value {
+			t.Fatalf("%s = %s, want %s", key, file[key], value)
+		}
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Fatalf("mode = %v, want the mode of the replaced file", info.Mode().Perm())
+	}
+
+	cfg.PromptWordCount = 0
+	if err := Save(path, cfg); err == nil {
+		t.Fatal("expected error for an invalid config")
+	}
+	if again, _ := os.ReadFile(path); !bytes.Equal(again, saved) {
+		t.Fatal("an invalid config must leave the file alone")
+	}
+}
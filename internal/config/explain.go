@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"tuitype/internal/theme"
+)
+
+// Sources of a Setting.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	// SourceEnv marks the theme forced by NO_COLOR.
+	SourceEnv = "env"
+)
+
+// Setting is a resolved config value and where it came from.
+type Setting struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// Explain loads the config file at path like Load and returns every
+// resolved value by config key with its source. The older go_* keys count
+// as setting code_examples and code_endpoints, and themes as setting theme.
+func Explain(path string) (map[string]Setting, error) {
+	rc, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	file := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+	source := func(keys ...string) string {
+		for _, k := range keys {
+			if _, ok := file[k]; ok {
+				return SourceFile
+			}
+		}
+		return SourceDefault
+	}
+
+	th := struct {
+		Name string `json:"name"`
+		theme.Theme
+	}{rc.Theme.Name, rc.Theme}
+	settings := map[string]Setting{
+		"normal_words":       {rc.Words, source("normal_words")},
+		"special_char_words": {rc.SpecialCharWords, source("special_char_words")},
+		"durations":          {rc.DurationLabels, source("durations")},
+		"word_counts":        {rc.WordCounts, source("word_counts")},
+		"prompt_word_count":  {rc.PromptWordCount, source("prompt_word_count")},
+		"quote_endpoint":     {rc.QuoteEndpoint, source("quote_endpoint")},
+		"code_examples":      {rc.CodeExamples, source("code_examples", "go_examples")},
+		"code_endpoints":     {rc.CodeEndpoints, source("code_endpoints", "go_example_endpoint")},
+		"code_dir":           {rc.CodeDir, source("code_dir")},
+		"seed":               {rc.Seed, source("seed")},
+		"skip_indent":        {rc.SkipIndent, source("skip_indent")},
+		"theme":              {th, source("theme", "themes")},
+		"keybindings":        {rc.Keys, source("keybindings")},
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		settings["theme"] = Setting{th, SourceEnv}
+	}
+	return settings, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"tuitype/internal/atomicfile"
+)
+
+// Save writes cfg to the config file at path once Resolve accepts it. Only
+// the keys whose values differ from what Read returns for the file are
+// written; all other keys, including ones this version does not know, are
+// kept as they are. The file is replaced atomically.
+func Save(path string, cfg AppConfig) error {
+	if _, err := Resolve(cfg); err != nil {
+		return err
+	}
+	old, err := Read(path)
+	if err != nil {
+		return err
+	}
+	file := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("parse config %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	before, err := fields(old)
+	if err != nil {
+		return err
+	}
+	after, err := fields(cfg)
+	if err != nil {
+		return err
+	}
+	for key, value := range after {
+		if !bytes.Equal(value, before[key]) {
+			file[key] = value
+		}
+	}
+	data, err = json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return writeFile(path, append(data, '\n'))
+}
+
+// fields encodes cfg as its top-level keys.
+func fields(cfg AppConfig) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	out := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return out, nil
+}
+
+// writeFile replaces path with data atomically, keeping the mode of the
+// file it replaces.
+func writeFile(path string, data []byte) error {
+	if err := atomicfile.Write(path, data); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kind classifies a recorded keystroke.
+type Kind uint8
+
+const (
+	// KindCorrect is a rune that matched the prompt at the cursor.
+	KindCorrect Kind = iota
+	// KindIncorrect is a rune that did not match and was kept as an error.
+	KindIncorrect
+	// KindCorrection is a rune that repaired the previous wrong slot.
+	KindCorrection
+	// KindBackspace removed the rune at Pos.
+	KindBackspace
+	// KindExtra is a rune typed past the end of the final prompt; it is
+	// scored as an error but not kept.
+	KindExtra
+	// KindSkip is indentation filled in by Options.SkipIndent; it is not a
+	// keystroke.
+	KindSkip
+)
+
+var kindNames = []string{"correct", "incorrect", "correction", "backspace", "extra", "skip"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("kind-%d", int(k))
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	if int(k) >= len(kindNames) {
+		return nil, fmt.Errorf("unknown keystroke kind %d", int(k))
+	}
+	return []byte(kindNames[k]), nil
+}
+
+func (k *Kind) UnmarshalText(text []byte) error {
+	for i, name := range kindNames {
+		if name == string(text) {
+			*k = Kind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown keystroke kind %q", text)
+}
+
+// Event is one entry of a session's keystroke log.
+type Event struct {
+	// Rune is the typed rune, or the removed rune for backspaces.
+	Rune rune `json:"r"`
+	// Expected is the prompt rune at Pos, zero past the end of the prompt.
+	Expected rune `json:"e"`
+	// Prompt is the index of the prompt within the session.
+	Prompt int `json:"p"`
+	// Pos is the rune offset within the prompt the keystroke applied to.
+	Pos int `json:"i"`
+	// Offset is the monotonic time since the first keystroke.
+	Offset time.Duration `json:"t"`
+	Kind   Kind          `json:"k"`
+}
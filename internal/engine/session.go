@@ -0,0 +1,331 @@
+package engine
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NextFunc returns the prompt that follows previous.
+type NextFunc func(previous string) string
+
+type Options struct {
+	// AutoAdvance loads the next prompt as soon as the current one is fully
+	// typed instead of on the following keystroke.
+	AutoAdvance bool
+	// Words ends the session once this many words have been typed across
+	// all prompts; the prompt that reaches the limit is cut to size. Zero
+	// means no limit.
+	Words int
+	// Prompts ends the session after this many prompts. Zero means no
+	// limit.
+	Prompts int
+	// SkipIndent fills in the spaces and tabs that start a line once its
+	// newline has been typed, the way an editor auto-indents. The skipped
+	// runes are logged as KindSkip.
+	SkipIndent bool
+}
+
+// Session scores keystrokes against a sequence of prompts. It holds no
+// timers of its own; callers pass the time of every keystroke so the same
+// rules apply to live input, replays and headless benchmarks.
+type Session struct {
+	next      NextFunc
+	opts      Options
+	prompt    []rune
+	input     []rune
+	prompts   []string
+	typed     int
+	correct   int
+	started   bool
+	startedAt time.Time
+	events    []Event
+	// pausedFor is the time spent in finished pauses; a pause in progress
+	// began at pausedAt.
+	pausedFor time.Duration
+	paused    bool
+	pausedAt  time.Time
+	// skipped marks the input slots of the current prompt filled by
+	// SkipIndent.
+	skipped map[int]bool
+
+	// wordsLoaded counts the words of every prompt loaded so far and
+	// wordsBefore those of the prompts already left behind.
+	wordsLoaded int
+	wordsBefore int
+	final       bool
+	done        bool
+	finishedAt  time.Time
+}
+
+func New(prompt string, next NextFunc, opts Options) *Session {
+	s := &Session{next: next, opts: opts}
+	s.load(prompt)
+	return s
+}
+
+// load makes p the current prompt, applying the word and prompt limits.
+func (s *Session) load(p string) {
+	s.prompt = []rune(p)
+	if s.opts.Prompts > 0 && len(s.prompts)+1 >= s.opts.Prompts {
+		s.final = true
+	}
+	if s.opts.Words > 0 {
+		left := s.opts.Words - s.wordsLoaded
+		if countWords(p) >= left {
+			s.prompt = []rune(firstWords(p, left))
+			s.final = true
+		}
+	}
+	s.wordsLoaded += countWords(string(s.prompt))
+}
+
+func countWords(p string) int {
+	return len(strings.Fields(p))
+}
+
+// firstWords returns p cut right after its n-th word, keeping the original
+// spacing between the words.
+func firstWords(p string, n int) string {
+	inWord := false
+	for i, r := range p {
+		space := unicode.IsSpace(r)
+		if inWord && space {
+			n--
+			if n == 0 {
+				return p[:i]
+			}
+		}
+		inWord = !space
+	}
+	return p
+}
+
+// Pause stops the session clock at at. Only a running session can be
+// paused.
+func (s *Session) Pause(at time.Time) {
+	if !s.started || s.done || s.paused {
+		return
+	}
+	s.paused = true
+	s.pausedAt = at
+}
+
+// Resume restarts the session clock at at; the pause does not count as
+// session time. A keystroke resumes a paused session by itself.
+func (s *Session) Resume(at time.Time) {
+	if !s.paused {
+		return
+	}
+	s.paused = false
+	if at.After(s.pausedAt) {
+		s.pausedFor += at.Sub(s.pausedAt)
+	}
+}
+
+// Type scores r against the current prompt. The first keystroke starts the
+// session clock at at. Once the session is done further keys are ignored.
+func (s *Session) Type(r rune, at time.Time) {
+	if s.done {
+		return
+	}
+	s.Resume(at)
+	if !s.started {
+		s.started = true
+		s.startedAt = at
+	}
+	if len(s.input) >= len(s.prompt) && !s.final {
+		s.advance()
+	}
+	if len(s.input) == 0 {
+		s.skipIndent(at)
+	}
+	idx := len(s.input)
+	s.typed++
+	switch {
+	case idx < len(s.prompt) && r == s.prompt[idx]:
+		s.input = append(s.input, r)
+		s.correct++
+		s.record(KindCorrect, r, idx, at)
+	case idx > 0 && s.input[idx-1] != s.prompt[idx-1] && r == s.prompt[idx-1]:
+		// If the user immediately corrects the previously mistyped
+		// character, repair that slot instead of shifting everything.
+		s.input[idx-1] = r
+		s.correct++
+		s.record(KindCorrection, r, idx-1, at)
+	case idx >= len(s.prompt):
+		// Only the final prompt can be overrun; there is nothing to roll
+		// over to, so the key is scored as an error and dropped.
+		s.record(KindExtra, r, idx, at)
+	default:
+		s.input = append(s.input, r)
+		s.record(KindIncorrect, r, idx, at)
+	}
+	if n := len(s.input); n > 0 && n <= len(s.prompt) && s.input[n-1] == '\n' && s.prompt[n-1] == '\n' {
+		s.skipIndent(at)
+	}
+	if s.final && s.complete() {
+		s.done = true
+		s.finishedAt = at
+		return
+	}
+	if s.opts.AutoAdvance && !s.final && len(s.input) >= len(s.prompt) {
+		s.advance()
+	}
+}
+
+// complete reports whether the current prompt is fully typed and ends on a
+// correct character.
+func (s *Session) complete() bool {
+	n := len(s.input)
+	return n > 0 && n == len(s.prompt) && s.input[n-1] == s.prompt[n-1]
+}
+
+// skipIndent fills the spaces and tabs at the cursor, which is at the start
+// of a line.
+func (s *Session) skipIndent(at time.Time) {
+	if !s.opts.SkipIndent {
+		return
+	}
+	for idx := len(s.input); idx < len(s.prompt) && (s.prompt[idx] == ' ' || s.prompt[idx] == '\t'); idx++ {
+		if s.skipped == nil {
+			s.skipped = map[int]bool{}
+		}
+		s.skipped[idx] = true
+		s.input = append(s.input, s.prompt[idx])
+		s.record(KindSkip, s.prompt[idx], idx, at)
+	}
+}
+
+// Backspace removes the last typed rune, taking back the keystroke and, if
+// it matched the prompt, the correct count it earned. Skipped indentation
+// is removed like typed input but was never counted.
+func (s *Session) Backspace(at time.Time) {
+	if s.done || len(s.input) == 0 {
+		return
+	}
+	s.Resume(at)
+	idx := len(s.input) - 1
+	removed := s.input[idx]
+	if s.skipped[idx] {
+		delete(s.skipped, idx)
+		s.input = s.input[:idx]
+		s.record(KindBackspace, removed, idx, at)
+		return
+	}
+	if idx < len(s.prompt) && s.prompt[idx] == removed && s.correct > 0 {
+		s.correct--
+	}
+	if s.typed > 0 {
+		s.typed--
+	}
+	s.input = s.input[:idx]
+	s.record(KindBackspace, removed, idx, at)
+}
+
+func (s *Session) record(kind Kind, r rune, pos int, at time.Time) {
+	var expected rune
+	if pos < len(s.prompt) {
+		expected = s.prompt[pos]
+	}
+	s.events = append(s.events, Event{
+		Rune:     r,
+		Expected: expected,
+		Prompt:   len(s.prompts),
+		Pos:      pos,
+		Offset:   at.Sub(s.startedAt) - s.pausedFor,
+		Kind:     kind,
+	})
+}
+
+// Events returns a copy of the keystroke log in the order it was recorded.
+func (s *Session) Events() []Event {
+	return append([]Event(nil), s.events...)
+}
+
+func (s *Session) advance() {
+	previous := string(s.prompt)
+	s.prompts = append(s.prompts, previous)
+	s.wordsBefore = s.wordsLoaded
+	s.input = s.input[:0]
+	s.skipped = nil
+	if s.next != nil {
+		s.load(s.next(previous))
+	} else {
+		s.load(previous)
+	}
+}
+
+// Snapshot is a read-only copy of the session state.
+type Snapshot struct {
+	Prompt string
+	Input  []rune
+	// Prompts lists every prompt the session has shown, the current one last.
+	Prompts   []string
+	Typed     int
+	Correct   int
+	Started   bool
+	StartedAt time.Time
+	// Done is set once a word or prompt limited session has been
+	// completed, at FinishedAt.
+	Done       bool
+	FinishedAt time.Time
+	// Paused is set while the clock is stopped, since PausedAt. PausedFor
+	// is the time spent in earlier pauses.
+	Paused    bool
+	PausedAt  time.Time
+	PausedFor time.Duration
+	// Words counts the words typed so far across all prompts.
+	Words int
+}
+
+// Elapsed is the session time at at: the time since the first keystroke
+// without the pauses.
+func (s Snapshot) Elapsed(at time.Time) time.Duration {
+	if !s.Started {
+		return 0
+	}
+	if s.Paused && at.After(s.PausedAt) {
+		at = s.PausedAt
+	}
+	return at.Sub(s.StartedAt) - s.PausedFor
+}
+
+func (s *Session) Snapshot() Snapshot {
+	prompts := make([]string, 0, len(s.prompts)+1)
+	prompts = append(prompts, s.prompts...)
+	prompts = append(prompts, string(s.prompt))
+	return Snapshot{
+		Prompt:     string(s.prompt),
+		Input:      append([]rune(nil), s.input...),
+		Prompts:    prompts,
+		Typed:      s.typed,
+		Correct:    s.correct,
+		Started:    s.started,
+		StartedAt:  s.startedAt,
+		Done:       s.done,
+		FinishedAt: s.finishedAt,
+		Paused:     s.paused,
+		PausedAt:   s.pausedAt,
+		PausedFor:  s.pausedFor,
+		Words:      s.wordsTyped(),
+	}
+}
+
+// wordsTyped counts the words of earlier prompts plus the word boundaries
+// passed in the current one.
+func (s *Session) wordsTyped() int {
+	n := s.wordsBefore
+	inWord := false
+	for _, r := range s.prompt[:len(s.input)] {
+		space := unicode.IsSpace(r)
+		if inWord && space {
+			n++
+		}
+		inWord = !space
+	}
+	if inWord && len(s.input) == len(s.prompt) {
+		n++
+	}
+	return n
+}
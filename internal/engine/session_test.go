@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func typeString(s *Session, text string) {
+	for _, r := range text {
+		s.Type(r, t0)
+	}
+}
+
+func TestTypeScoresCorrectAndWrong(t *testing.T) {
+	s := New("abc", nil, Options{})
+	typeString(s, "axc")
+	snap := s.Snapshot()
+	if string(snap.Input) != "axc" || snap.Typed != 3 || snap.Correct != 2 {
+		t.Fatalf("snapshot = %+v, want input axc typed 3 correct 2", snap)
+	}
+	if !snap.Started || !snap.StartedAt.Equal(t0) {
+		t.Fatalf("session not started at first keystroke: %+v", snap)
+	}
+}
+
+func TestImmediateCorrectionRepairsPreviousSlot(t *testing.T) {
+	s := New("ab", nil, Options{})
+	typeString(s, "xa")
+	snap := s.Snapshot()
+	if string(snap.Input) != "a" || snap.Typed != 2 || snap.Correct != 1 {
+		t.Fatalf("snapshot = %+v, want input a typed 2 correct 1", snap)
+	}
+}
+
+func TestBackspaceTakesBackKeystroke(t *testing.T) {
+	s := New("ab", nil, Options{})
+	typeString(s, "a")
+	s.Backspace(t0)
+	snap := s.Snapshot()
+	if len(snap.Input) != 0 || snap.Typed != 0 || snap.Correct != 0 {
+		t.Fatalf("snapshot = %+v, want empty", snap)
+	}
+	s.Backspace(t0)
+	if snap := s.Snapshot(); snap.Typed != 0 {
+		t.Fatalf("backspace on empty input changed Typed to %d", snap.Typed)
+	}
+}
+
+func TestRolloverOnNextKeystroke(t *testing.T) {
+	s := New("ab", func(previous string) string { return previous + "!" }, Options{})
+	typeString(s, "ab")
+	if got := s.Snapshot().Prompt; got != "ab" {
+		t.Fatalf("prompt rolled over early: %q", got)
+	}
+	typeString(s, "a")
+	snap := s.Snapshot()
+	if snap.Prompt != "ab!" || string(snap.Input) != "a" {
+		t.Fatalf("snapshot = %+v, want prompt ab! input a", snap)
+	}
+	if len(snap.Prompts) != 2 || snap.Prompts[0] != "ab" {
+		t.Fatalf("Prompts = %q, want [ab ab!]", snap.Prompts)
+	}
+}
+
+func TestAutoAdvance(t *testing.T) {
+	s := New("ab", func(string) string { return "cd" }, Options{AutoAdvance: true})
+	typeString(s, "ab")
+	snap := s.Snapshot()
+	if snap.Prompt != "cd" || len(snap.Input) != 0 {
+		t.Fatalf("snapshot = %+v, want fresh prompt cd", snap)
+	}
+}
+
+func FuzzSessionInvariants(f *testing.F) {
+	f.Add("hello world.", "helo\bworld", true, false)
+	f.Add("{}[]", "}{\b\b{}", false, false)
+	f.Add("if x {\n\treturn\n}", "if x {\n\b\b\nretun\b\brn\n}", false, true)
+	f.Fuzz(func(t *testing.T, prompt, keys string, auto, skip bool) {
+		if prompt == "" {
+			return
+		}
+		s := New(prompt, func(previous string) string { return previous }, Options{AutoAdvance: auto, SkipIndent: skip})
+		for _, r := range keys {
+			if r == '\b' {
+				s.Backspace(t0)
+			} else {
+				s.Type(r, t0)
+			}
+			snap := s.Snapshot()
+			if snap.Correct < 0 || snap.Correct > snap.Typed {
+				t.Fatalf("Correct %d out of range for Typed %d", snap.Correct, snap.Typed)
+			}
+			if len(snap.Input) > len([]rune(snap.Prompt)) {
+				t.Fatalf("input %q longer than prompt %q", string(snap.Input), snap.Prompt)
+			}
+		}
+	})
+}
+
+func TestSkipIndentFillsLeadingWhitespace(t *testing.T) {
+	s := New("if x {\n  \tgo()\n}", nil, Options{Prompts: 1, SkipIndent: true})
+	typeString(s, "if x {\n")
+	if snap := s.Snapshot(); string(snap.Input) != "if x {\n  \t" || snap.Typed != 7 || snap.Correct != 7 {
+		t.Fatalf("after newline input = %q, typed %d, correct %d", string(snap.Input), snap.Typed, snap.Correct)
+	}
+
+	// Backspacing into the indentation takes nothing back.
+	s.Backspace(t0)
+	s.Type('\t', t0)
+	typeString(s, "go()\n}")
+	snap := s.Snapshot()
+	if !snap.Done || snap.Typed != 14 || snap.Correct != 14 {
+		t.Fatalf("snapshot = %+v, want done with 14 typed and correct", snap)
+	}
+	skips := 0
+	for _, ev := range s.Events() {
+		if ev.Kind == KindSkip {
+			skips++
+		}
+	}
+	if skips != 3 {
+		t.Fatalf("skip events = %d, want 3", skips)
+	}
+}
+
+func TestEventsRecordKeystrokes(t *testing.T) {
+	s := New("ab", func(string) string { return "cd" }, Options{})
+	s.Type('x', t0)
+	s.Type('a', t0.Add(100*time.Millisecond))
+	s.Type('b', t0.Add(200*time.Millisecond))
+	s.Backspace(t0.Add(300 * time.Millisecond))
+	s.Type('b', t0.Add(400*time.Millisecond))
+	s.Type('c', t0.Add(500*time.Millisecond))
+
+	want := []Event{
+		{Rune: 'x', Expected: 'a', Prompt: 0, Pos: 0, Offset: 0, Kind: KindIncorrect},
+		{Rune: 'a', Expected: 'a', Prompt: 0, Pos: 0, Offset: 100 * time.Millisecond, Kind: KindCorrection},
+		{Rune: 'b', Expected: 'b', Prompt: 0, Pos: 1, Offset: 200 * time.Millisecond, Kind: KindCorrect},
+		{Rune: 'b', Expected: 'b', Prompt: 0, Pos: 1, Offset: 300 * time.Millisecond, Kind: KindBackspace},
+		{Rune: 'b', Expected: 'b', Prompt: 0, Pos: 1, Offset: 400 * time.Millisecond, Kind: KindCorrect},
+		{Rune: 'c', Expected: 'c', Prompt: 1, Pos: 0, Offset: 500 * time.Millisecond, Kind: KindCorrect},
+	}
+	got := s.Events()
+	if len(got) != len(want) {
+		t.Fatalf("len(Events) = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPauseIsNotSessionTime(t *testing.T) {
+	s := New("abc", nil, Options{Prompts: 1})
+	s.Pause(t0)
+	if s.Snapshot().Paused {
+		t.Fatal("a session that has not started cannot be paused")
+	}
+	s.Type('a', t0)
+	s.Pause(t0.Add(time.Second))
+	snap := s.Snapshot()
+	if !snap.Paused || snap.Elapsed(t0.Add(time.Minute)) != time.Second {
+		t.Fatalf("paused elapsed = %v, want the clock stopped at 1s", snap.Elapsed(t0.Add(time.Minute)))
+	}
+	s.Resume(t0.Add(11 * time.Second))
+	s.Type('b', t0.Add(12*time.Second))
+	s.Pause(t0.Add(13 * time.Second))
+	// A keystroke ends the pause too.
+	s.Type('c', t0.Add(23*time.Second))
+
+	snap = s.Snapshot()
+	if !snap.Done || snap.Paused || snap.PausedFor != 20*time.Second {
+		t.Fatalf("done %v, paused %v for %v; want done after 20s of pauses", snap.Done, snap.Paused, snap.PausedFor)
+	}
+	if got := snap.Elapsed(snap.FinishedAt); got != 3*time.Second {
+		t.Fatalf("Elapsed = %v, want 3s", got)
+	}
+	events := s.Events()
+	if events[1].Offset != 2*time.Second || events[2].Offset != 3*time.Second {
+		t.Fatalf("offsets = %v %v, want 2s 3s", events[1].Offset, events[2].Offset)
+	}
+}
+
+func TestKindJSONRoundTrip(t *testing.T) {
+	data, err := KindCorrection.MarshalText()
+	if err != nil || string(data) != "correction" {
+		t.Fatalf("MarshalText = %q, %v", data, err)
+	}
+	var k Kind
+	if err := k.UnmarshalText([]byte("backspace")); err != nil || k != KindBackspace {
+		t.Fatalf("UnmarshalText = %v, %v", k, err)
+	}
+	if err := k.UnmarshalText([]byte("bogus")); err == nil {
+		t.Fatal("expected error for unknown kind")
+	}
+}
+
+func TestWordLimitCutsFinalPromptAndEndsOnLastCorrectChar(t *testing.T) {
+	s := New("one two three.", func(string) string { return "four  five six." }, Options{Words: 5})
+	typeString(s, "one two three. ")
+	snap := s.Snapshot()
+	if snap.Prompt != "four  five" {
+		t.Fatalf("final prompt = %q, want %q", snap.Prompt, "four  five")
+	}
+	if snap.Words != 3 {
+		t.Fatalf("Words after first prompt = %d, want 3", snap.Words)
+	}
+
+	typeString(s, "our  fiv")
+	s.Type('x', t0.Add(time.Second))
+	if s.Snapshot().Done {
+		t.Fatal("session done on a wrong final character")
+	}
+	s.Type('e', t0.Add(2*time.Second))
+	snap = s.Snapshot()
+	if !snap.Done || !snap.FinishedAt.Equal(t0.Add(2*time.Second)) {
+		t.Fatalf("snapshot = %+v, want done at the correcting keystroke", snap)
+	}
+	if snap.Words != 5 {
+		t.Fatalf("Words = %d, want 5", snap.Words)
+	}
+
+	typed := snap.Typed
+	s.Type('z', t0.Add(3*time.Second))
+	if s.Snapshot().Typed != typed {
+		t.Fatal("keys after the end were scored")
+	}
+}
+
+func TestFinalPromptOverrunIsExtra(t *testing.T) {
+	s := New("ab", nil, Options{Prompts: 1})
+	typeString(s, "ax")
+	s.Type('q', t0)
+	snap := s.Snapshot()
+	if snap.Done || string(snap.Input) != "ax" || snap.Typed != 3 || snap.Correct != 1 {
+		t.Fatalf("snapshot = %+v, want input ax typed 3 correct 1", snap)
+	}
+	events := s.Events()
+	if last := events[len(events)-1]; last.Kind != KindExtra || last.Pos != 2 {
+		t.Fatalf("last event = %+v, want extra at 2", last)
+	}
+	s.Type('b', t0)
+	if !s.Snapshot().Done {
+		t.Fatal("correcting the final slot should finish a single-prompt session")
+	}
+}
+
+func TestFirstWords(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"a b c", 2, "a b"},
+		{"a  b\tc", 2, "a  b"},
+		{"a b", 5, "a b"},
+		{"for i := 0\n\tx++", 3, "for i :="},
+	}
+	for _, c := range cases {
+		if got := firstWords(c.in, c.n); got != c.want {
+			t.Fatalf("firstWords(%q, %d) = %q, want %q", c.in, c.n, got, c.want)
+		}
+	}
+}
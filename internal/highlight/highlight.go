@@ -0,0 +1,100 @@
+// Package highlight classifies the characters of a code prompt by token so
+// the typing view can colour keywords, strings and operators apart.
+//
+// Go is tokenized with go/scanner. The other languages of the code mode use
+// a small lexer that knows their keywords, comments and string quotes; it
+// does not parse, so odd constructs fall back to plain text rather than
+// failing.
+package highlight
+
+import (
+	"go/scanner"
+	"go/token"
+	"unicode/utf8"
+)
+
+// Class is the token class of a character.
+type Class uint8
+
+const (
+	Plain Class = iota
+	Keyword
+	// Builtin is a predeclared type or function, e.g. string or len.
+	Builtin
+	String
+	Number
+	Comment
+	Operator
+)
+
+var classNames = [...]string{"plain", "keyword", "builtin", "string", "number", "comment", "operator"}
+
+func (c Class) String() string {
+	if int(c) < len(classNames) {
+		return classNames[c]
+	}
+	return "unknown"
+}
+
+// Classes returns the class of every rune of src, read as code of the
+// language lang (a code mode language name such as "go" or "python").
+// Unknown languages are all Plain.
+func Classes(lang, src string) []Class {
+	classes := make([]Class, utf8.RuneCountInString(src))
+	if lang == "go" {
+		classifyGo(src, classes)
+	} else if lx, ok := lexers[lang]; ok {
+		lx.classify([]rune(src), classes)
+	}
+	return classes
+}
+
+var goBuiltins = words(`any bool byte comparable complex64 complex128 error float32 float64
+	int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr
+	true false iota nil
+	append cap clear close complex copy delete imag len make max min new panic print println real recover`)
+
+func classifyGo(src string, classes []Class) {
+	// runeAt maps the byte offset of each rune to its index.
+	runeAt := make([]int, len(src))
+	r := 0
+	for off := range src {
+		runeAt[off] = r
+		r++
+	}
+	fill := func(start, end int, c Class) {
+		for off := start; off < end && off < len(src); off++ {
+			if utf8.RuneStart(src[off]) {
+				classes[runeAt[off]] = c
+			}
+		}
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return
+		}
+		start := file.Offset(pos)
+		switch {
+		case tok == token.SEMICOLON && lit != ";":
+			// An automatic semicolon at a line break or the end.
+		case tok == token.COMMENT:
+			fill(start, start+len(lit), Comment)
+		case tok == token.STRING, tok == token.CHAR:
+			fill(start, start+len(lit), String)
+		case tok == token.INT, tok == token.FLOAT, tok == token.IMAG:
+			fill(start, start+len(lit), Number)
+		case tok.IsKeyword():
+			fill(start, start+len(lit), Keyword)
+		case tok == token.IDENT && goBuiltins[lit]:
+			fill(start, start+len(lit), Builtin)
+		case tok.IsOperator():
+			fill(start, start+len(tok.String()), Operator)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+)
+
+// spans lists the runs of runes of the same class as class:text, without
+// the plain runs and surrounding spaces.
+func spans(src string, classes []Class) string {
+	runes := []rune(src)
+	var out []string
+	for i := 0; i < len(runes); {
+		end := i + 1
+		for end < len(runes) && classes[end] == classes[i] {
+			end++
+		}
+		if text := strings.TrimSpace(string(runes[i:end])); classes[i] != Plain && text != "" {
+			out = append(out, classes[i].String()+":"+text)
+		}
+		i = end
+	}
+	return strings.Join(out, " ")
+}
+
+func TestClasses(t *testing.T) {
+	cases := []struct {
+		lang string
+		src  string
+		want string
+	}{
+		{
+			lang: "go",
+			src:  "func f(s string) int {\n    return len(s) + 0x1f // ok\n}",
+			want: "keyword:func operator:( builtin:string operator:) builtin:int operator:{ keyword:return builtin:len operator:( operator:) operator:+ number:0x1f comment:// ok operator:}",
+		},
+		{
+			lang: "go",
+			src:  "s := `é\n` + \"x\"",
+			want: "operator::= string:`é\n` operator:+ string:\"x\"",
+		},
+		{
+			lang: "python",
+			src:  "def f(x='a#b'):  # note\n    return None",
+			want: "keyword:def operator:( operator:= string:'a#b' operator:): comment:# note keyword:return keyword:None",
+		},
+		{
+			lang: "javascript",
+			src:  "const s = `hi ${name}`; /* c */",
+			want: "keyword:const operator:= string:`hi ${name}` operator:; comment:/* c */",
+		},
+		{
+			lang: "rust",
+			src:  "fn f<'a>(c: char) -> bool { c == 'x' }",
+			want: "keyword:fn operator:<' operator:>( operator:: builtin:char operator:) operator:-> builtin:bool operator:{ operator:== string:'x' operator:}",
+		},
+		{
+			lang: "sql",
+			src:  "select count(*) from t -- all",
+			want: "keyword:select builtin:count operator:(*) keyword:from comment:-- all",
+		},
+		{
+			lang: "shell",
+			src:  "echo \"${#xs}\" # n",
+			want: "builtin:echo string:\"${#xs}\" comment:# n",
+		},
+		{
+			lang: "cobol",
+			src:  "MOVE A TO B.",
+			want: "",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.lang, func(t *testing.T) {
+			classes := Classes(c.lang, c.src)
+			if len(classes) != len([]rune(c.src)) {
+				t.Fatalf("%d classes for %d runes", len(classes), len([]rune(c.src)))
+			}
+			if got := spans(c.src, classes); got != c.want {
+				t.Fatalf("spans =\n%s\nwant\n%s", got, c.want)
+			}
+		})
+	}
+}
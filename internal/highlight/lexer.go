@@ -0,0 +1,228 @@
+package highlight
+
+import (
+	"strings"
+	"unicode"
+)
+
+// lexer tokenizes the languages without a Go standard library scanner.
+type lexer struct {
+	keywords map[string]bool
+	builtins map[string]bool
+	// foldCase matches keywords and builtins case-insensitively (SQL).
+	foldCase      bool
+	lineComments  []string
+	blockComments [][2]string
+	// quotes are the string delimiters. A multiline quote, such as a
+	// template literal or a triple quote, may span lines.
+	quotes    []string
+	multiline map[string]bool
+	// charQuote is a quote that only opens a literal if it closes within
+	// a few characters, so Rust lifetimes stay plain.
+	charQuote rune
+}
+
+var lexers = map[string]*lexer{
+	"python": {
+		keywords: words(`False None True and as assert async await break case class continue def del
+			elif else except finally for from global if import in is lambda match nonlocal not or
+			pass raise return try while with yield`),
+		builtins: words(`abs all any bool bytes dict enumerate filter float int isinstance len list map
+			max min object open print range repr set sorted str sum super tuple type zip`),
+		lineComments: []string{"#"},
+		quotes:       []string{`"""`, "'''", `"`, "'"},
+		multiline:    words(`""" '''`),
+	},
+	"javascript": {
+		keywords:      words(jsKeywords),
+		builtins:      words(jsBuiltins),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, "'", "`"},
+		multiline:     words("`"),
+	},
+	"typescript": {
+		keywords: words(jsKeywords + ` abstract declare enum implements interface keyof namespace
+			private protected public readonly type`),
+		builtins: words(jsBuiltins + ` any boolean never number object string symbol unknown void
+			Partial Pick Readonly Record`),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, "'", "`"},
+		multiline:     words("`"),
+	},
+	"rust": {
+		keywords: words(`as async await break const continue crate dyn else enum extern false fn for
+			if impl in let loop match mod move mut pub ref return self Self static struct super
+			trait true type unsafe use where while`),
+		builtins: words(`bool char f32 f64 i8 i16 i32 i64 i128 isize str u8 u16 u32 u64 u128 usize
+			Box Err None Ok Option Result Some String Vec`),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`},
+		charQuote:     '\'',
+	},
+	"sql": {
+		keywords: words(`ADD ALL ALTER AND AS ASC BETWEEN BY CASE CONFLICT CREATE DEFAULT DELETE DESC
+			DISTINCT DO DROP ELSE END EXISTS FALSE FOREIGN FROM FULL GROUP HAVING IN INDEX INNER
+			INSERT INTO IS JOIN KEY LEFT LIKE LIMIT NOT NOTHING NULL OFFSET ON OR ORDER OUTER PRIMARY
+			REFERENCES RETURNING RIGHT SELECT SET TABLE THEN TRUE UNION UNIQUE UPDATE VALUES WHEN
+			WHERE WITH`),
+		builtins: words(`AVG BIGINT BOOLEAN COALESCE COUNT DATE INTEGER LOWER MAX MIN NOW SERIAL SUM
+			TEXT TIMESTAMP UPPER VARCHAR`),
+		foldCase:      true,
+		lineComments:  []string{"--"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{"'", `"`},
+	},
+	"shell": {
+		keywords: words(`case do done elif else esac fi for function if in local readonly return
+			select then until while`),
+		builtins:     words(`cd echo eval exec exit export printf read set shift source test trap unset`),
+		lineComments: []string{"#"},
+		quotes:       []string{`"`, "'"},
+		multiline:    words(`" '`),
+	},
+}
+
+const jsKeywords = `async await break case catch class const continue debugger default delete do
+	else export extends false finally for function if import in instanceof let new null of
+	return static super switch this throw true try typeof undefined var void while with yield`
+
+const jsBuiltins = `Array Date Error JSON Map Math Number Object Promise Set String console
+	parseFloat parseInt`
+
+// words builds a set from a space-separated list.
+func words(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+func (lx *lexer) classify(src []rune, classes []Class) {
+	fill := func(start, end int, c Class) {
+		for i := start; i < end; i++ {
+			classes[i] = c
+		}
+	}
+	at := func(i int, s string) bool {
+		return strings.HasPrefix(string(src[i:min(len(src), i+len(s))]), s)
+	}
+
+	for i := 0; i < len(src); {
+		r := src[i]
+		if end, ok := lx.comment(src, i, at); ok {
+			fill(i, end, Comment)
+			i = end
+			continue
+		}
+		if end, ok := lx.quoted(src, i, at); ok {
+			fill(i, end, String)
+			i = end
+			continue
+		}
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			end := i + 1
+			for end < len(src) && (isWord(src[end]) || src[end] == '.' && end+1 < len(src) && unicode.IsDigit(src[end+1])) {
+				end++
+			}
+			fill(i, end, Number)
+			i = end
+		case isWord(r):
+			end := i + 1
+			for end < len(src) && isWord(src[end]) {
+				end++
+			}
+			word := string(src[i:end])
+			if lx.foldCase {
+				word = strings.ToUpper(word)
+			}
+			switch {
+			case lx.keywords[word]:
+				fill(i, end, Keyword)
+			case lx.builtins[word]:
+				fill(i, end, Builtin)
+			}
+			i = end
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			classes[i] = Operator
+			i++
+		default:
+			i++
+		}
+	}
+}
+
+// comment reports the end of a comment starting at i. A "#" comment must
+// start a word, as in shell "${#list}" it is an operator.
+func (lx *lexer) comment(src []rune, i int, at func(int, string) bool) (int, bool) {
+	for _, c := range lx.lineComments {
+		if !at(i, c) || c == "#" && i > 0 && !unicode.IsSpace(src[i-1]) {
+			continue
+		}
+		end := i
+		for end < len(src) && src[end] != '\n' {
+			end++
+		}
+		return end, true
+	}
+	for _, bc := range lx.blockComments {
+		if !at(i, bc[0]) {
+			continue
+		}
+		for end := i + len(bc[0]); end < len(src); end++ {
+			if at(end, bc[1]) {
+				return end + len(bc[1]), true
+			}
+		}
+		return len(src), true
+	}
+	return 0, false
+}
+
+// quoted reports the end of a string literal starting at i. Backslash
+// escapes the next character; a literal that is not multiline ends at the
+// line break if it is not closed before.
+func (lx *lexer) quoted(src []rune, i int, at func(int, string) bool) (int, bool) {
+	if lx.charQuote != 0 && src[i] == lx.charQuote {
+		for end := i + 1; end < len(src) && end <= i+3; end++ {
+			if src[end] == '\\' {
+				end++
+				continue
+			}
+			if src[end] == lx.charQuote && end > i+1 {
+				return end + 1, true
+			}
+		}
+		return 0, false
+	}
+	for _, q := range lx.quotes {
+		if !at(i, q) {
+			continue
+		}
+		end := i + len(q)
+		for end < len(src) {
+			switch {
+			case src[end] == '\\':
+				end += 2
+				continue
+			case at(end, q):
+				return end + len(q), true
+			case src[end] == '\n' && !lx.multiline[q]:
+				return end, true
+			}
+			end++
+		}
+		return len(src), true
+	}
+	return 0, false
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
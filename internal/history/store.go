@@ -0,0 +1,218 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"tuitype/internal/engine"
+	"tuitype/internal/metrics"
+)
+
+// SchemaVersion is written into every record. Bump it when the record
+// layout changes and teach migrate how to read the older versions.
+//
+// Version 2 added Keystrokes. Version 3 stores net WPM in WPM; older
+// records held correct characters / 5 per minute.
+const SchemaVersion = 3
+
+// Test length kinds stored in Session.Kind. Records without a kind are
+// timed tests.
+const (
+	KindTime   = "time"
+	KindWords  = "words"
+	KindPrompt = "prompt"
+)
+
+// Session is one completed typing test as stored on disk.
+type Session struct {
+	Version      int           `json:"v"`
+	ID           string        `json:"id"`
+	StartedAt    time.Time     `json:"started_at"`
+	FinishedAt   time.Time     `json:"finished_at"`
+	Mode         string        `json:"mode"`
+	Duration     time.Duration `json:"duration_ns"`
+	TotalTyped   int           `json:"total_typed"`
+	TotalCorrect int           `json:"total_correct"`
+	// WPM is the net WPM; see package metrics for the formulas.
+	WPM    float64 `json:"wpm"`
+	RawWPM float64 `json:"raw_wpm,omitempty"`
+	// LegacyWPM marks a version 1 or 2 record without a keystroke log,
+	// whose WPM is still correct characters / 5 per minute.
+	LegacyWPM bool     `json:"legacy_wpm,omitempty"`
+	Accuracy  float64  `json:"accuracy"`
+	Prompts   []string `json:"prompts"`
+	// Keystrokes is the full keystroke log; empty for version 1 records.
+	Keystrokes []engine.Event `json:"keystrokes,omitempty"`
+	// Seed reproduces the locally generated prompts; zero if unknown.
+	Seed int64 `json:"seed,omitempty"`
+	// Kind is how the test length was measured. Duration is the configured
+	// length of a timed test and the actual elapsed time otherwise.
+	Kind string `json:"kind,omitempty"`
+	// Words is the target of a word-count test.
+	Words int `json:"words,omitempty"`
+	// Language is the code language of a code practice test.
+	Language string `json:"language,omitempty"`
+	// Paused is the time the test spent paused between StartedAt and
+	// FinishedAt. It counts neither in Duration nor in the keystroke
+	// offsets.
+	Paused time.Duration `json:"paused_ns,omitempty"`
+}
+
+// LengthKind returns Kind, treating an empty kind as a timed test.
+func (s Session) LengthKind() string {
+	if s.Kind == "" {
+		return KindTime
+	}
+	return s.Kind
+}
+
+// NewID derives a session identifier from its start time.
+func NewID(startedAt time.Time) string {
+	return startedAt.UTC().Format("20060102-150405")
+}
+
+// Find returns the session with the given id, preferring the most recent
+// record if the id repeats.
+func Find(sessions []Session, id string) (Session, bool) {
+	for i := len(sessions) - 1; i >= 0; i-- {
+		if sessions[i].ID == id {
+			return sessions[i], true
+		}
+	}
+	return Session{}, false
+}
+
+// Store appends sessions to a JSON Lines file, one record per line.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append writes sess as a single line and syncs it to disk. A record that
+// was torn by a crash mid-write is terminated first so it cannot corrupt
+// the new one; Load skips such partial lines.
+func (s *Store) Append(sess Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess.Version = SchemaVersion
+	line, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("encode session: %w", err)
+	}
+	line = append(line, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create history dir: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open history %s: %w", s.path, err)
+	}
+	defer f.Close()
+
+	torn, err := endsMidLine(f)
+	if err != nil {
+		return fmt.Errorf("inspect history %s: %w", s.path, err)
+	}
+	if torn {
+		line = append([]byte{'\n'}, line...)
+	}
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("write history %s: %w", s.path, err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync history %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func endsMidLine(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() == 0 {
+		return false, nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// Load returns every readable session in file order. A missing file is
+// not an error. Lines that cannot be decoded (torn writes) or that were
+// written by a newer schema are skipped.
+func (s *Store) Load() ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open history %s: %w", s.path, err)
+	}
+	defer f.Close()
+	return decode(f)
+}
+
+func decode(r io.Reader) ([]Session, error) {
+	var out []Session
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var sess Session
+			if json.Unmarshal(line, &sess) == nil && migrate(&sess) {
+				out = append(out, sess)
+			}
+		}
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, fmt.Errorf("read history: %w", err)
+		}
+	}
+}
+
+// migrate upgrades sess in place to SchemaVersion and reports whether the
+// record is usable. Records before version 3 get their net WPM recomputed
+// from the keystroke log, or are marked LegacyWPM without one.
+func migrate(sess *Session) bool {
+	switch sess.Version {
+	case 1, 2:
+		sess.Version = SchemaVersion
+		if len(sess.Keystrokes) == 0 {
+			sess.LegacyWPM = true
+			return true
+		}
+		res := metrics.Compute(sess.Keystrokes, sess.Duration)
+		sess.WPM, sess.RawWPM = res.NetWPM, res.RawWPM
+		return true
+	case SchemaVersion:
+		return true
+	default:
+		return false
+	}
+}
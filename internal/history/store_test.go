@@ -0,0 +1,133 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tuitype/internal/engine"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "nested", "history.jsonl"))
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		err := store.Append(Session{
+			ID:        NewID(start.Add(time.Duration(i) * time.Minute)),
+			StartedAt: start,
+			Mode:      "normal",
+			Duration:  30 * time.Second,
+			WPM:       float64(60 + i),
+			Prompts:   []string{"a b c."},
+		})
+		if err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("len(sessions) = %d, want 2", len(got))
+	}
+	if got[1].WPM != 61 || got[1].Version != SchemaVersion {
+		t.Fatalf("second session = %+v", got[1])
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	got, err := NewStore(filepath.Join(t.TempDir(), "missing.jsonl")).Load()
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Load = (%v, %v), want empty, nil", got, err)
+	}
+}
+
+func TestAppendAfterTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	torn := `{"v":1,"id":"a","mode":"normal"}` + "\n" + `{"v":1,"id":"b","mo`
+	if err := os.WriteFile(path, []byte(torn), 0o644); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+	store := NewStore(path)
+	if err := store.Append(Session{ID: "c", Mode: "quote"}); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "c" {
+		t.Fatalf("sessions = %+v, want ids a and c", got)
+	}
+}
+
+func TestLoadSkipsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	data := `{"v":1,"id":"a"}` + "\n" + `{"v":99,"id":"future"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+	got, err := NewStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "a" {
+		t.Fatalf("sessions = %+v, want only id a", got)
+	}
+}
+
+func TestLoadMigratesVersionOne(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte(`{"v":1,"id":"old","wpm":50}`+"\n"), 0o644); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+	store := NewStore(path)
+	if err := store.Append(Session{ID: "new", Keystrokes: []engine.Event{{Rune: 'a', Expected: 'a', Kind: engine.KindCorrect}}}); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(got) != 2 || got[0].Version != SchemaVersion || len(got[0].Keystrokes) != 0 || !got[0].LegacyWPM {
+		t.Fatalf("sessions = %+v", got)
+	}
+	if got[1].LegacyWPM {
+		t.Fatal("a new record must not be marked legacy")
+	}
+	if len(got[1].Keystrokes) != 1 || got[1].Keystrokes[0].Kind != engine.KindCorrect {
+		t.Fatalf("keystrokes not round-tripped: %+v", got[1].Keystrokes)
+	}
+}
+
+func TestLoadRecomputesVersionTwoWPM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	// Ten keystrokes in a minute, one error left standing: 2 raw, 1 net
+	// WPM. The record still holds the old correct-characters speed.
+	var events []string
+	for i := 0; i < 9; i++ {
+		events = append(events, fmt.Sprintf(`{"r":97,"e":97,"i":%d,"k":"correct"}`, i))
+	}
+	events = append(events, `{"r":98,"e":97,"i":9,"k":"incorrect"}`)
+	keys := "[" + strings.Join(events, ",") + "]"
+	data := `{"v":2,"id":"old","duration_ns":60000000000,"wpm":1.8,"keystrokes":` + keys + `}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+	got, err := NewStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].Version != SchemaVersion || got[0].LegacyWPM {
+		t.Fatalf("sessions = %+v", got)
+	}
+	if got[0].WPM != 1 || got[0].RawWPM != 2 {
+		t.Fatalf("WPM = %v, raw %v; want net 1, raw 2", got[0].WPM, got[0].RawWPM)
+	}
+}
@@ -0,0 +1,178 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// AccuracyBands are the lower bounds of the accuracy distribution buckets
+// reported by Summarize, in ascending order.
+var AccuracyBands = []float64{0, 90, 95, 98}
+
+// Summary aggregates the sessions of one mode and test length. Duration is
+// zero unless Kind is KindTime; Words is zero unless Kind is KindWords.
+// Sessions with LegacyWPM are summarized apart, with LegacyWPM set.
+type Summary struct {
+	Mode        string        `json:"mode"`
+	Kind        string        `json:"kind"`
+	Duration    time.Duration `json:"duration_ns"`
+	Words       int           `json:"words,omitempty"`
+	LegacyWPM   bool          `json:"legacy_wpm,omitempty"`
+	Sessions    int           `json:"sessions"`
+	BestWPM     float64       `json:"best_wpm"`
+	AverageWPM  float64       `json:"average_wpm"`
+	MedianWPM   float64       `json:"median_wpm"`
+	AvgAccuracy float64       `json:"average_accuracy"`
+	Trend       Trend         `json:"trend"`
+	// AccuracyCounts[i] counts sessions whose accuracy falls into the band
+	// starting at AccuracyBands[i].
+	AccuracyCounts []int `json:"accuracy_counts"`
+}
+
+// Trend describes the sessions inside the trailing window of Days days.
+type Trend struct {
+	Days       int     `json:"days"`
+	Sessions   int     `json:"sessions"`
+	AverageWPM float64 `json:"average_wpm"`
+	// WPMPerDay is the least-squares slope of WPM against time.
+	WPMPerDay float64 `json:"wpm_per_day"`
+}
+
+type summaryKey struct {
+	mode     string
+	kind     string
+	duration time.Duration
+	words    int
+	legacy   bool
+}
+
+var kindOrder = map[string]int{KindTime: 0, KindWords: 1, KindPrompt: 2}
+
+// Summarize groups sessions by mode and test length. Groups are ordered by
+// mode, then timed tests by duration, word tests by word count and single
+// prompts last, with the legacy sessions of a length after the others.
+// The trend window ends at now and spans the last days days.
+func Summarize(sessions []Session, now time.Time, days int) []Summary {
+	groups := map[summaryKey][]Session{}
+	for _, s := range sessions {
+		k := summaryKey{mode: s.Mode, kind: s.LengthKind(), legacy: s.LegacyWPM}
+		switch k.kind {
+		case KindTime:
+			k.duration = s.Duration
+		case KindWords:
+			k.words = s.Words
+		}
+		groups[k] = append(groups[k], s)
+	}
+	keys := make([]summaryKey, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].mode != keys[j].mode {
+			return keys[i].mode < keys[j].mode
+		}
+		if keys[i].kind != keys[j].kind {
+			return kindOrder[keys[i].kind] < kindOrder[keys[j].kind]
+		}
+		if keys[i].duration != keys[j].duration {
+			return keys[i].duration < keys[j].duration
+		}
+		if keys[i].words != keys[j].words {
+			return keys[i].words < keys[j].words
+		}
+		return !keys[i].legacy && keys[j].legacy
+	})
+
+	out := make([]Summary, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, summarize(k, groups[k], now, days))
+	}
+	return out
+}
+
+func summarize(k summaryKey, sessions []Session, now time.Time, days int) Summary {
+	sum := Summary{
+		Mode:           k.mode,
+		Kind:           k.kind,
+		Duration:       k.duration,
+		Words:          k.words,
+		LegacyWPM:      k.legacy,
+		Sessions:       len(sessions),
+		AccuracyCounts: make([]int, len(AccuracyBands)),
+		Trend:          Trend{Days: days},
+	}
+	wpms := make([]float64, 0, len(sessions))
+	var accTotal float64
+	for _, s := range sessions {
+		wpms = append(wpms, s.WPM)
+		sum.AverageWPM += s.WPM
+		if s.WPM > sum.BestWPM {
+			sum.BestWPM = s.WPM
+		}
+		accTotal += s.Accuracy
+		sum.AccuracyCounts[accuracyBand(s.Accuracy)]++
+	}
+	sum.AverageWPM /= float64(len(sessions))
+	sum.AvgAccuracy = accTotal / float64(len(sessions))
+	sum.MedianWPM = median(wpms)
+
+	cutoff := now.Add(-time.Duration(days) * 24 * time.Hour)
+	var xs, ys []float64
+	for _, s := range sessions {
+		if s.StartedAt.Before(cutoff) || s.StartedAt.After(now) {
+			continue
+		}
+		xs = append(xs, s.StartedAt.Sub(cutoff).Hours()/24)
+		ys = append(ys, s.WPM)
+		sum.Trend.AverageWPM += s.WPM
+	}
+	sum.Trend.Sessions = len(ys)
+	if len(ys) > 0 {
+		sum.Trend.AverageWPM /= float64(len(ys))
+	}
+	sum.Trend.WPMPerDay = slope(xs, ys)
+	return sum
+}
+
+func accuracyBand(acc float64) int {
+	band := 0
+	for i, lower := range AccuracyBands {
+		if acc >= lower {
+			band = i
+		}
+	}
+	return band
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+func slope(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+	var sx, sy, sxx, sxy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxx += xs[i] * xs[i]
+		sxy += xs[i] * ys[i]
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return 0
+	}
+	return (n*sxy - sx*sy) / den
+}
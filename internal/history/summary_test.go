@@ -0,0 +1,92 @@
+package history
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSummarizeGroupsByModeAndDuration(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		{Mode: "normal", Duration: 30 * time.Second, StartedAt: now.Add(-20 * 24 * time.Hour), WPM: 40, Accuracy: 89},
+		{Mode: "normal", Duration: 30 * time.Second, StartedAt: now.Add(-2 * 24 * time.Hour), WPM: 50, Accuracy: 96},
+		{Mode: "normal", Duration: 30 * time.Second, StartedAt: now.Add(-1 * 24 * time.Hour), WPM: 60, Accuracy: 99},
+		{Mode: "normal", Duration: time.Minute, StartedAt: now, WPM: 70, Accuracy: 100},
+		{Mode: "code", Duration: 30 * time.Second, StartedAt: now, WPM: 30, Accuracy: 92},
+	}
+
+	got := Summarize(sessions, now, 7)
+	if len(got) != 3 {
+		t.Fatalf("len(summaries) = %d, want 3", len(got))
+	}
+	if got[0].Mode != "code" || got[1].Duration != 30*time.Second || got[2].Duration != time.Minute {
+		t.Fatalf("unexpected ordering: %+v", got)
+	}
+
+	s := got[1]
+	if s.Sessions != 3 || s.BestWPM != 60 || s.AverageWPM != 50 || s.MedianWPM != 50 {
+		t.Fatalf("normal/30s summary = %+v", s)
+	}
+	if want := []int{1, 0, 1, 1}; !equalInts(s.AccuracyCounts, want) {
+		t.Fatalf("AccuracyCounts = %v, want %v", s.AccuracyCounts, want)
+	}
+	if s.Trend.Sessions != 2 || s.Trend.AverageWPM != 55 {
+		t.Fatalf("Trend = %+v, want 2 sessions averaging 55", s.Trend)
+	}
+	if math.Abs(s.Trend.WPMPerDay-10) > 1e-9 {
+		t.Fatalf("WPMPerDay = %v, want 10", s.Trend.WPMPerDay)
+	}
+}
+
+func TestMedianEvenCount(t *testing.T) {
+	if got := median([]float64{4, 1, 3, 2}); got != 2.5 {
+		t.Fatalf("median = %v, want 2.5", got)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSummarizeGroupsByTestLength(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		{Mode: "normal", Kind: KindPrompt, Duration: 9 * time.Second, StartedAt: now, WPM: 70},
+		{Mode: "normal", Kind: KindWords, Words: 50, Duration: 40 * time.Second, StartedAt: now, WPM: 60},
+		{Mode: "normal", Kind: KindWords, Words: 10, Duration: 8 * time.Second, StartedAt: now, WPM: 55},
+		{Mode: "normal", Kind: KindWords, Words: 10, Duration: 11 * time.Second, StartedAt: now, WPM: 45},
+		{Mode: "normal", Duration: 30 * time.Second, StartedAt: now, WPM: 50},
+	}
+
+	got := Summarize(sessions, now, 7)
+	if len(got) != 4 {
+		t.Fatalf("len(summaries) = %d, want 4", len(got))
+	}
+	if got[0].Kind != KindTime || got[1].Words != 10 || got[2].Words != 50 || got[3].Kind != KindPrompt {
+		t.Fatalf("unexpected ordering: %+v", got)
+	}
+	if got[1].Sessions != 2 || got[1].AverageWPM != 50 || got[1].Duration != 0 {
+		t.Fatalf("normal/10 words summary = %+v", got[1])
+	}
+}
+
+func TestSummarizeKeepsLegacyWPMApart(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		{Mode: "normal", Duration: 30 * time.Second, StartedAt: now, WPM: 80, LegacyWPM: true},
+		{Mode: "normal", Duration: 30 * time.Second, StartedAt: now, WPM: 50},
+	}
+	got := Summarize(sessions, now, 7)
+	if len(got) != 2 || got[0].LegacyWPM || !got[1].LegacyWPM || got[0].AverageWPM != 50 {
+		t.Fatalf("summaries = %+v, want the legacy session in its own group", got)
+	}
+}
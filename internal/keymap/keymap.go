@@ -0,0 +1,251 @@
+// Package keymap binds the keys of the UI to actions, in the style of the
+// bubbles key package: a Binding holds the keys of one action and its help
+// text, and Matches tests a key message against bindings.
+//
+// The bindings can be replaced per action from the "keybindings" section of
+// the config file, e.g. {"menu-prev": ["k", "up"]}. Keys are written as
+// tea.KeyMsg.String() reports them: "enter", "esc", "ctrl+r", "a".
+package keymap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Binding is the set of keys bound to an action.
+type Binding struct {
+	keys []string
+	desc string
+}
+
+// NewBinding binds keys to an action described by desc in help.
+func NewBinding(desc string, keys ...string) Binding {
+	return Binding{keys: append([]string(nil), keys...), desc: desc}
+}
+
+// Keys returns the bound keys.
+func (b Binding) Keys() []string { return append([]string(nil), b.keys...) }
+
+// Enabled reports whether any key is bound.
+func (b Binding) Enabled() bool { return len(b.keys) > 0 }
+
+// WithDesc returns b described as desc, for screens where the action
+// reads differently.
+func (b Binding) WithDesc(desc string) Binding {
+	b.desc = desc
+	return b
+}
+
+// HelpKey is the key text of the help: the bound keys joined by "/", with
+// arrows drawn as arrows.
+func (b Binding) HelpKey() string {
+	names := make([]string, len(b.keys))
+	for i, k := range b.keys {
+		names[i] = keyName(k)
+	}
+	return strings.Join(names, "/")
+}
+
+// Help is the help entry of the binding, such as "ctrl+c quit", or ""
+// if no key is bound.
+func (b Binding) Help() string {
+	if !b.Enabled() {
+		return ""
+	}
+	return b.HelpKey() + " " + b.desc
+}
+
+var arrows = map[string]string{"left": "←", "right": "→", "up": "↑", "down": "↓", " ": "space"}
+
+func keyName(k string) string {
+	if a, ok := arrows[k]; ok {
+		return a
+	}
+	return k
+}
+
+// Matches reports whether msg is one of the keys of any of bindings.
+func Matches(msg tea.KeyMsg, bindings ...Binding) bool {
+	s := msg.String()
+	for _, b := range bindings {
+		for _, k := range b.keys {
+			if k == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HelpLine joins help entries with " • ", skipping empty ones.
+func HelpLine(entries ...string) string {
+	kept := entries[:0:0]
+	for _, e := range entries {
+		if e != "" {
+			kept = append(kept, e)
+		}
+	}
+	return strings.Join(kept, " • ")
+}
+
+// KeyMap holds the bindings of every action.
+type KeyMap struct {
+	// Quit and Back work on every screen. Back leaves a test or submenu
+	// for the mode menu.
+	Quit Binding
+	Back Binding
+	// Restart starts the test over on the same prompt; NewTest abandons it
+	// for a new test with the same settings. Both work during a test and
+	// on the results screen.
+	Restart Binding
+	NewTest Binding
+	// Pause stops and restarts the clock of a running test.
+	Pause Binding
+
+	MenuPrev      Binding
+	MenuNext      Binding
+	Select        Binding
+	Heatmap       Binding
+	HeatmapMetric Binding
+	Settings      Binding
+}
+
+// action ties a config name to a binding. Typing actions are active while
+// a test runs, so they cannot use keys that type text.
+type action struct {
+	name   string
+	b      *Binding
+	typing bool
+}
+
+func (k *KeyMap) actions() []action {
+	return []action{
+		{"quit", &k.Quit, true},
+		{"back-to-menu", &k.Back, true},
+		{"restart-test", &k.Restart, true},
+		{"new-test", &k.NewTest, true},
+		{"pause", &k.Pause, true},
+		{"menu-prev", &k.MenuPrev, false},
+		{"menu-next", &k.MenuNext, false},
+		{"select", &k.Select, false},
+		{"heatmap", &k.Heatmap, false},
+		{"heatmap-metric", &k.HeatmapMetric, false},
+		{"settings", &k.Settings, false},
+	}
+}
+
+// Default returns the built-in bindings.
+func Default() KeyMap {
+	return KeyMap{
+		Quit:          NewBinding("quit", "ctrl+c"),
+		Back:          NewBinding("menu", "esc"),
+		Restart:       NewBinding("restart", "ctrl+r"),
+		NewTest:       NewBinding("new test", "ctrl+n"),
+		Pause:         NewBinding("pause", "ctrl+p"),
+		MenuPrev:      NewBinding("prev", "left", "up", "k"),
+		MenuNext:      NewBinding("next", "right", "down", "j"),
+		Select:        NewBinding("select", "enter"),
+		Heatmap:       NewBinding("weak keys", "h"),
+		HeatmapMetric: NewBinding("errors/latency", "tab"),
+		Settings:      NewBinding("settings", "s"),
+	}
+}
+
+// Actions returns the config names of the actions in help order.
+func Actions() []string {
+	var k KeyMap
+	acts := k.actions()
+	names := make([]string, len(acts))
+	for i, a := range acts {
+		names[i] = a.name
+	}
+	return names
+}
+
+// MarshalJSON encodes the keys of every action by config name, the form of
+// the "keybindings" config section.
+func (k KeyMap) MarshalJSON() ([]byte, error) {
+	keys := map[string][]string{}
+	for _, a := range k.actions() {
+		keys[a.name] = a.b.Keys()
+	}
+	return json.Marshal(keys)
+}
+
+// New returns the default bindings with the keys of the actions in
+// overrides replaced. It rejects unknown actions, actions without keys,
+// typing actions bound to keys that type text, and keys bound to two
+// actions.
+func New(overrides map[string][]string) (KeyMap, error) {
+	k := Default()
+	acts := k.actions()
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		var act *action
+		for i := range acts {
+			if acts[i].name == name {
+				act = &acts[i]
+			}
+		}
+		if act == nil {
+			errs = append(errs, fmt.Errorf("unknown action %q (want one of %s)", name, strings.Join(Actions(), ", ")))
+			continue
+		}
+		keys := make([]string, 0, len(overrides[name]))
+		ok := true
+		for _, key := range overrides[name] {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				errs = append(errs, fmt.Errorf("%s: empty key", name))
+				ok = false
+				continue
+			}
+			if act.typing && typesText(key) {
+				errs = append(errs, fmt.Errorf("%s: %q types text during a test; bind a ctrl or alt key", name, key))
+				ok = false
+				continue
+			}
+			keys = append(keys, key)
+		}
+		if ok && len(keys) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no keys", name))
+		}
+		if ok && len(keys) > 0 {
+			act.b.keys = keys
+		}
+	}
+
+	owner := map[string]string{}
+	for _, a := range acts {
+		for _, key := range a.b.keys {
+			if other, ok := owner[key]; ok && other != a.name {
+				errs = append(errs, fmt.Errorf("%q is bound to both %s and %s", key, other, a.name))
+				continue
+			}
+			owner[key] = a.name
+		}
+	}
+	if len(errs) > 0 {
+		return KeyMap{}, errors.Join(errs...)
+	}
+	return k, nil
+}
+
+// typesText reports whether key is typed into the prompt during a test.
+func typesText(key string) bool {
+	switch key {
+	case "enter", "tab", "space", " ", "backspace":
+		return true
+	}
+	return len([]rune(key)) == 1
+}
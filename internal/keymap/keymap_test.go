@@ -0,0 +1,81 @@
+package keymap
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewOverridesBindings(t *testing.T) {
+	k, err := New(map[string][]string{"menu-next": {"n", "down"}, "restart-test": {"ctrl+t"}})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if !Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")}, k.MenuNext) {
+		t.Fatal("n should move to the next menu entry")
+	}
+	if Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}, k.MenuNext) {
+		t.Fatal("j should no longer be bound")
+	}
+	if Matches(tea.KeyMsg{Type: tea.KeyCtrlR}, k.Restart) || !Matches(tea.KeyMsg{Type: tea.KeyCtrlT}, k.Restart) {
+		t.Fatal("restart should be bound to ctrl+t only")
+	}
+	if got := k.Quit.Help(); got != "ctrl+c quit" {
+		t.Fatalf("Quit.Help() = %q, want the default binding", got)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	cases := []struct {
+		overrides map[string][]string
+		want      string
+	}{
+		{map[string][]string{"jump": {"x"}}, `unknown action "jump"`},
+		{map[string][]string{"select": {}}, "select: no keys"},
+		{map[string][]string{"select": {" "}}, "select: empty key"},
+		{map[string][]string{"restart-test": {"r"}}, `restart-test: "r" types text`},
+		{map[string][]string{"back-to-menu": {"tab"}}, `back-to-menu: "tab" types text`},
+		{map[string][]string{"heatmap": {"ctrl+r"}}, `"ctrl+r" is bound to both restart-test and heatmap`},
+	}
+	for _, c := range cases {
+		if _, err := New(c.overrides); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Fatalf("New(%v) error = %v, want %q", c.overrides, err, c.want)
+		}
+	}
+}
+
+func TestHelp(t *testing.T) {
+	k := Default()
+	if got := k.MenuPrev.HelpKey(); got != "←/↑/k" {
+		t.Fatalf("MenuPrev.HelpKey() = %q", got)
+	}
+	if got := k.Back.WithDesc("back").Help(); got != "esc back" {
+		t.Fatalf("Back.WithDesc().Help() = %q", got)
+	}
+	if got := HelpLine("a", "", NewBinding("none").Help(), k.Quit.Help()); got != "a • ctrl+c quit" {
+		t.Fatalf("HelpLine = %q", got)
+	}
+}
+
+func TestKeyMapJSON(t *testing.T) {
+	k, err := New(map[string][]string{"quit": {"ctrl+q"}})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	var got map[string][]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if len(got) != len(Actions()) || strings.Join(got["quit"], ",") != "ctrl+q" || strings.Join(got["select"], ",") != "enter" {
+		t.Fatalf("keys = %v", got)
+	}
+	if _, err := New(got); err != nil {
+		t.Fatalf("the encoded keys are not valid keybindings: %v", err)
+	}
+}
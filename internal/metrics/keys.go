@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"tuitype/internal/engine"
+)
+
+// KeyStat aggregates the keystrokes aimed at one prompt character.
+type KeyStat struct {
+	Key rune
+	// Presses counts the correct and incorrect keystrokes at the character.
+	Presses int
+	Errors  int
+	// Timed counts the correct keystrokes with a latency sample, Latency
+	// their summed time since the previous keystroke.
+	Timed   int
+	Latency time.Duration
+}
+
+// ErrorRate is the share of presses that were wrong, in percent.
+func (k KeyStat) ErrorRate() float64 {
+	if k.Presses == 0 {
+		return 0
+	}
+	return float64(k.Errors) / float64(k.Presses) * 100
+}
+
+// AvgLatency is the mean time from the previous keystroke to a correct
+// press of the key.
+func (k KeyStat) AvgLatency() time.Duration {
+	if k.Timed == 0 {
+		return 0
+	}
+	return k.Latency / time.Duration(k.Timed)
+}
+
+// Bigram is a pair of consecutive prompt characters.
+type Bigram [2]rune
+
+func (b Bigram) String() string {
+	return string(b[:])
+}
+
+// BigramStat aggregates the transitions between two characters typed
+// correctly one after the other.
+type BigramStat struct {
+	Bigram  Bigram
+	Samples int
+	Total   time.Duration
+}
+
+// Avg is the mean transition time.
+func (b BigramStat) Avg() time.Duration {
+	if b.Samples == 0 {
+		return 0
+	}
+	return b.Total / time.Duration(b.Samples)
+}
+
+// KeyReport aggregates per-character and per-bigram timings across any
+// number of keystroke logs. The zero value is ready to use.
+type KeyReport struct {
+	Keys    map[rune]*KeyStat
+	Bigrams map[Bigram]*BigramStat
+}
+
+// Add folds one session's keystroke log into the report. Latency is the
+// time since the previous keystroke of any kind, so hesitation before a
+// key is charged to that key. A bigram is sampled when both of its
+// characters were typed correctly back to back in the same prompt.
+func (r *KeyReport) Add(events []engine.Event) {
+	if r.Keys == nil {
+		r.Keys = map[rune]*KeyStat{}
+		r.Bigrams = map[Bigram]*BigramStat{}
+	}
+	for i, ev := range events {
+		if ev.Expected == 0 {
+			continue
+		}
+		switch ev.Kind {
+		case engine.KindCorrect, engine.KindCorrection:
+			k := r.key(ev.Expected)
+			k.Presses++
+			if i == 0 {
+				continue
+			}
+			prev := events[i-1]
+			k.Timed++
+			k.Latency += ev.Offset - prev.Offset
+			if ev.Kind == engine.KindCorrect && prev.Kind == engine.KindCorrect &&
+				prev.Prompt == ev.Prompt && prev.Pos == ev.Pos-1 {
+				bg := Bigram{prev.Expected, ev.Expected}
+				b, ok := r.Bigrams[bg]
+				if !ok {
+					b = &BigramStat{Bigram: bg}
+					r.Bigrams[bg] = b
+				}
+				b.Samples++
+				b.Total += ev.Offset - prev.Offset
+			}
+		case engine.KindIncorrect:
+			k := r.key(ev.Expected)
+			k.Presses++
+			k.Errors++
+		}
+	}
+}
+
+func (r *KeyReport) key(c rune) *KeyStat {
+	k, ok := r.Keys[c]
+	if !ok {
+		k = &KeyStat{Key: c}
+		r.Keys[c] = k
+	}
+	return k
+}
+
+// Key returns the stats of c, zero if it was never typed.
+func (r *KeyReport) Key(c rune) KeyStat {
+	if k, ok := r.Keys[c]; ok {
+		return *k
+	}
+	return KeyStat{Key: c}
+}
+
+// WorstKeys returns the keys with at least minPresses presses, highest
+// error rate first and slowest first among equal rates.
+func (r *KeyReport) WorstKeys(minPresses int) []KeyStat {
+	var out []KeyStat
+	for _, k := range r.Keys {
+		if k.Presses >= minPresses {
+			out = append(out, *k)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ei, ej := out[i].ErrorRate(), out[j].ErrorRate(); ei != ej {
+			return ei > ej
+		}
+		if li, lj := out[i].AvgLatency(), out[j].AvgLatency(); li != lj {
+			return li > lj
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// SlowestKeys returns the keys with at least minSamples latency samples,
+// slowest first.
+func (r *KeyReport) SlowestKeys(minSamples int) []KeyStat {
+	var out []KeyStat
+	for _, k := range r.Keys {
+		if k.Timed >= minSamples {
+			out = append(out, *k)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if li, lj := out[i].AvgLatency(), out[j].AvgLatency(); li != lj {
+			return li > lj
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// SlowestBigrams returns the bigrams with at least minSamples samples,
+// slowest first.
+func (r *KeyReport) SlowestBigrams(minSamples int) []BigramStat {
+	var out []BigramStat
+	for _, b := range r.Bigrams {
+		if b.Samples >= minSamples {
+			out = append(out, *b)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ai, aj := out[i].Avg(), out[j].Avg(); ai != aj {
+			return ai > aj
+		}
+		return string(out[i].Bigram[:]) < string(out[j].Bigram[:])
+	})
+	return out
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"tuitype/internal/engine"
+)
+
+func TestKeyReport(t *testing.T) {
+	events := []engine.Event{
+		key(engine.KindCorrect, 'a', 'a', 0, 0),
+		key(engine.KindCorrect, '{', '{', 1, ms(400)),
+		key(engine.KindIncorrect, '[', '|', 2, ms(500)),
+		key(engine.KindCorrection, '|', '|', 2, ms(900)),
+		key(engine.KindCorrect, 'a', 'a', 3, ms(1000)),
+		key(engine.KindBackspace, 'a', 'a', 3, ms(1100)),
+		key(engine.KindCorrect, 'a', 'a', 3, ms(1200)),
+		key(engine.KindExtra, 'q', 0, 4, ms(1300)),
+	}
+	var r KeyReport
+	r.Add(events)
+	r.Add(events)
+
+	cases := []struct {
+		key     rune
+		presses int
+		errors  int
+		latency time.Duration
+	}{
+		{'a', 6, 0, 100 * time.Millisecond},
+		{'{', 2, 0, 400 * time.Millisecond},
+		{'|', 4, 2, 400 * time.Millisecond},
+		{'q', 0, 0, 0},
+	}
+	for _, c := range cases {
+		k := r.Key(c.key)
+		if k.Presses != c.presses || k.Errors != c.errors || k.AvgLatency() != c.latency {
+			t.Fatalf("Key(%q) = %+v (avg %v), want %d presses %d errors avg %v", c.key, k, k.AvgLatency(), c.presses, c.errors, c.latency)
+		}
+	}
+	if got := r.Key('|').ErrorRate(); got != 50 {
+		t.Fatalf("ErrorRate('|') = %v, want 50", got)
+	}
+
+	bigrams := r.SlowestBigrams(1)
+	if len(bigrams) != 1 || bigrams[0].Bigram != (Bigram{'a', '{'}) || bigrams[0].Samples != 2 || bigrams[0].Avg() != 400*time.Millisecond {
+		t.Fatalf("SlowestBigrams = %+v, want only a{ at 400ms", bigrams)
+	}
+	if worst := r.WorstKeys(2); len(worst) != 3 || worst[0].Key != '|' {
+		t.Fatalf("WorstKeys = %+v, want | first", worst)
+	}
+	if slow := r.SlowestKeys(2); len(slow) != 3 || slow[0].Key != '{' || slow[2].Key != 'a' {
+		t.Fatalf("SlowestKeys = %+v, want { | a", slow)
+	}
+}
@@ -0,0 +1,240 @@
+// Package metrics scores a typing test from its keystroke log. It is the
+// single place speed and accuracy are calculated; the live stats line, the
+// results screen, the ghost and the saved history all use it.
+//
+// The formulas follow the usual typing-test conventions. A "word" is five
+// keystrokes and minutes is the time since the first keystroke:
+//
+//	keystrokes   typed keys except backspaces: correct, incorrect,
+//	             correction and extra keys; indentation skipped for the
+//	             typist is not a keystroke
+//	raw WPM      keystrokes / 5 / minutes
+//	uncorrected  characters still wrong when the test ends, plus extra keys
+//	             typed past the end of the final prompt
+//	net WPM      max(0, raw WPM - uncorrected / minutes)
+//	accuracy     correct keystrokes / keystrokes * 100, where correct
+//	             keystrokes are correct keys and corrections; 100 before
+//	             the first keystroke
+//	KPS          keystrokes / seconds
+//	consistency  max(0, 100 - coefficient of variation of the per-second
+//	             raw WPM, in percent)
+//
+// A corrected error therefore costs accuracy and raw speed but not net
+// speed, while an error left standing costs one word per minute of net WPM.
+package metrics
+
+import (
+	"math"
+	"time"
+
+	"tuitype/internal/engine"
+)
+
+// Result holds the scores of a keystroke log over an elapsed time.
+type Result struct {
+	Keystrokes  int
+	Correct     int
+	Uncorrected int
+	RawWPM      float64
+	NetWPM      float64
+	Accuracy    float64
+	KPS         float64
+	Consistency float64
+}
+
+// Chars classifies every prompt character at the end of a test.
+type Chars struct {
+	Correct   int
+	Incorrect int
+	Extra     int
+	Missed    int
+}
+
+// Timeline is the per-second history of a test. Index i covers the time
+// from i to i+1 seconds after the first keystroke; the last second may be
+// partial.
+type Timeline struct {
+	// WPM is the net WPM from the first keystroke to the end of each second.
+	WPM []float64
+	// Raw is the raw WPM of the keystrokes inside each second.
+	Raw []float64
+	// Errors counts the incorrect and extra keystrokes inside each second.
+	Errors []int
+}
+
+type slot struct{ prompt, pos int }
+
+// tally replays a keystroke log onto the prompt slots it touched.
+type tally struct {
+	keystrokes int
+	correct    int
+	extra      int
+	wrong      int
+	slots      map[slot]bool
+}
+
+func newTally() *tally {
+	return &tally{slots: map[slot]bool{}}
+}
+
+func (t *tally) apply(ev engine.Event) {
+	at := slot{ev.Prompt, ev.Pos}
+	switch ev.Kind {
+	case engine.KindCorrect, engine.KindCorrection:
+		t.keystrokes++
+		t.correct++
+		t.fill(at, true)
+	case engine.KindIncorrect:
+		t.keystrokes++
+		t.fill(at, false)
+	case engine.KindExtra:
+		t.keystrokes++
+		t.extra++
+	case engine.KindSkip:
+		t.fill(at, true)
+	case engine.KindBackspace:
+		if ok, filled := t.slots[at]; filled && !ok {
+			t.wrong--
+		}
+		delete(t.slots, at)
+	}
+}
+
+func (t *tally) fill(at slot, ok bool) {
+	if was, filled := t.slots[at]; filled && !was {
+		t.wrong--
+	}
+	if !ok {
+		t.wrong++
+	}
+	t.slots[at] = ok
+}
+
+func (t *tally) uncorrected() int {
+	return t.wrong + t.extra
+}
+
+// Compute scores events over elapsed. Speeds are zero for a non-positive
+// elapsed time.
+func Compute(events []engine.Event, elapsed time.Duration) Result {
+	t := newTally()
+	for _, ev := range events {
+		t.apply(ev)
+	}
+	return Result{
+		Keystrokes:  t.keystrokes,
+		Correct:     t.correct,
+		Uncorrected: t.uncorrected(),
+		RawWPM:      RawWPM(t.keystrokes, elapsed),
+		NetWPM:      NetWPM(t.keystrokes, t.uncorrected(), elapsed),
+		Accuracy:    Accuracy(t.correct, t.keystrokes),
+		KPS:         KPS(t.keystrokes, elapsed),
+		Consistency: Consistency(PerSecond(events, elapsed).Raw),
+	}
+}
+
+// RawWPM is keystrokes / 5 / minutes.
+func RawWPM(keystrokes int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(keystrokes) / 5 / elapsed.Minutes()
+}
+
+// NetWPM is raw WPM minus uncorrected errors per minute, floored at zero.
+func NetWPM(keystrokes, uncorrected int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return math.Max(0, RawWPM(keystrokes, elapsed)-float64(uncorrected)/elapsed.Minutes())
+}
+
+// Accuracy is the percentage of keystrokes that were correct, 100 if
+// nothing was typed.
+func Accuracy(correct, keystrokes int) float64 {
+	if keystrokes == 0 {
+		return 100
+	}
+	return float64(correct) / float64(keystrokes) * 100
+}
+
+// KPS is keystrokes per second.
+func KPS(keystrokes int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(keystrokes) / elapsed.Seconds()
+}
+
+// Consistency is 100 minus the coefficient of variation of values, in
+// percent, floored at zero: a steady per-second speed scores close to 100.
+func Consistency(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	if mean == 0 {
+		return 0
+	}
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	cv := math.Sqrt(variance/float64(len(values))) / mean * 100
+	return math.Max(0, 100-cv)
+}
+
+// PerSecond splits events into the seconds of elapsed. Events at or after
+// elapsed count towards the last second.
+func PerSecond(events []engine.Event, elapsed time.Duration) Timeline {
+	var tl Timeline
+	seconds := int((elapsed + time.Second - 1) / time.Second)
+	t := newTally()
+	next := 0
+	for i := 0; i < seconds; i++ {
+		start, end := time.Duration(i)*time.Second, time.Duration(i+1)*time.Second
+		last := i == seconds-1
+		if last {
+			end = elapsed
+		}
+		keys, errs := t.keystrokes, t.keystrokes-t.correct
+		for ; next < len(events) && (last || events[next].Offset < end); next++ {
+			t.apply(events[next])
+		}
+		tl.WPM = append(tl.WPM, NetWPM(t.keystrokes, t.uncorrected(), end))
+		tl.Raw = append(tl.Raw, RawWPM(t.keystrokes-keys, end-start))
+		tl.Errors = append(tl.Errors, t.keystrokes-t.correct-errs)
+	}
+	return tl
+}
+
+// Breakdown classifies the characters of prompts, the prompts of a test in
+// order. Missed characters are the untyped rest of prompts the test moved
+// past, and of the final prompt when finished is set because the engine
+// ended the test there.
+func Breakdown(events []engine.Event, prompts []string, finished bool) Chars {
+	t := newTally()
+	for _, ev := range events {
+		t.apply(ev)
+	}
+	c := Chars{Extra: t.extra}
+	filled := map[int]int{}
+	for at, ok := range t.slots {
+		filled[at.prompt]++
+		if ok {
+			c.Correct++
+		} else {
+			c.Incorrect++
+		}
+	}
+	for i, p := range prompts {
+		if i < len(prompts)-1 || finished {
+			c.Missed += max(0, len([]rune(p))-filled[i])
+		}
+	}
+	return c
+}
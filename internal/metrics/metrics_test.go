@@ -0,0 +1,221 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tuitype/internal/engine"
+)
+
+func ms(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+
+func key(kind engine.Kind, r, expected rune, pos int, at time.Duration) engine.Event {
+	return engine.Event{Kind: kind, Rune: r, Expected: expected, Pos: pos, Offset: at}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestCompute(t *testing.T) {
+	cases := []struct {
+		name    string
+		events  []engine.Event
+		elapsed time.Duration
+		want    Result
+	}{
+		{
+			name:    "empty",
+			elapsed: time.Minute,
+			want:    Result{Accuracy: 100},
+		},
+		{
+			name: "all correct",
+			events: []engine.Event{
+				key(engine.KindCorrect, 'a', 'a', 0, 0),
+				key(engine.KindCorrect, 'b', 'b', 1, ms(500)),
+				key(engine.KindCorrect, 'c', 'c', 2, ms(1000)),
+				key(engine.KindCorrect, 'd', 'd', 3, ms(1500)),
+				key(engine.KindCorrect, 'e', 'e', 4, ms(2000)),
+			},
+			elapsed: 6 * time.Second,
+			want:    Result{Keystrokes: 5, Correct: 5, RawWPM: 10, NetWPM: 10, Accuracy: 100, KPS: 5.0 / 6},
+		},
+		{
+			// The error stays in the input: it costs a word per minute of
+			// net speed on top of the accuracy.
+			name: "uncorrected error",
+			events: []engine.Event{
+				key(engine.KindCorrect, 'a', 'a', 0, 0),
+				key(engine.KindIncorrect, 'x', 'b', 1, ms(100)),
+				key(engine.KindCorrect, 'c', 'c', 2, ms(200)),
+				key(engine.KindCorrect, 'd', 'd', 3, ms(300)),
+				key(engine.KindCorrect, 'e', 'e', 4, ms(400)),
+			},
+			elapsed: 6 * time.Second,
+			want:    Result{Keystrokes: 5, Correct: 4, Uncorrected: 1, RawWPM: 10, NetWPM: 0, Accuracy: 80, KPS: 5.0 / 6},
+		},
+		{
+			// Fixing an error with the immediate-correction rule or with a
+			// backspace keeps net speed but not accuracy.
+			name: "corrected errors",
+			events: []engine.Event{
+				key(engine.KindIncorrect, 'x', 'a', 0, 0),
+				key(engine.KindCorrection, 'a', 'a', 0, ms(100)),
+				key(engine.KindIncorrect, 'y', 'b', 1, ms(200)),
+				key(engine.KindBackspace, 'y', 'b', 1, ms(300)),
+				key(engine.KindCorrect, 'b', 'b', 1, ms(400)),
+			},
+			elapsed: 6 * time.Second,
+			want:    Result{Keystrokes: 4, Correct: 2, RawWPM: 8, NetWPM: 8, Accuracy: 50, KPS: 4.0 / 6},
+		},
+		{
+			name: "extra keys count as uncorrected",
+			events: []engine.Event{
+				key(engine.KindCorrect, 'a', 'a', 0, 0),
+				key(engine.KindExtra, 'q', 0, 1, ms(100)),
+				key(engine.KindExtra, 'q', 0, 1, ms(200)),
+			},
+			elapsed: 3 * time.Second,
+			want:    Result{Keystrokes: 3, Correct: 1, Uncorrected: 2, RawWPM: 12, NetWPM: 0, Accuracy: 100.0 / 3, KPS: 1},
+		},
+		{
+			name: "skipped indentation is not a keystroke",
+			events: []engine.Event{
+				key(engine.KindCorrect, '\n', '\n', 0, 0),
+				key(engine.KindSkip, ' ', ' ', 1, 0),
+				key(engine.KindSkip, ' ', ' ', 2, 0),
+				key(engine.KindCorrect, 'x', 'x', 3, ms(500)),
+			},
+			elapsed: 6 * time.Second,
+			want:    Result{Keystrokes: 2, Correct: 2, RawWPM: 4, NetWPM: 4, Accuracy: 100, KPS: 2.0 / 6},
+		},
+		{
+			name:    "no elapsed time",
+			events:  []engine.Event{key(engine.KindCorrect, 'a', 'a', 0, 0)},
+			elapsed: 0,
+			want:    Result{Keystrokes: 1, Correct: 1, Accuracy: 100},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Compute(c.events, c.elapsed)
+			got.Consistency = 0
+			if got.Keystrokes != c.want.Keystrokes || got.Correct != c.want.Correct || got.Uncorrected != c.want.Uncorrected ||
+				!near(got.RawWPM, c.want.RawWPM) || !near(got.NetWPM, c.want.NetWPM) ||
+				!near(got.Accuracy, c.want.Accuracy) || !near(got.KPS, c.want.KPS) {
+				t.Fatalf("Compute = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestConsistency(t *testing.T) {
+	cases := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{0, 0}, 0},
+		{[]float64{60, 60, 60}, 100},
+		{[]float64{40, 60}, 80},
+		{[]float64{0, 100, 0, 0}, 0},
+	}
+	for _, c := range cases {
+		if got := Consistency(c.values); !near(got, c.want) {
+			t.Fatalf("Consistency(%v) = %v, want %v", c.values, got, c.want)
+		}
+	}
+}
+
+func TestPerSecond(t *testing.T) {
+	events := []engine.Event{
+		key(engine.KindCorrect, 'a', 'a', 0, 0),
+		key(engine.KindCorrect, 'b', 'b', 1, ms(500)),
+		key(engine.KindIncorrect, 'x', 'c', 2, ms(1200)),
+		key(engine.KindBackspace, 'x', 'c', 2, ms(1300)),
+		key(engine.KindCorrect, 'c', 'c', 2, ms(1400)),
+		key(engine.KindBackspace, 'c', 'c', 2, ms(1450)),
+		key(engine.KindCorrect, 'c', 'c', 2, ms(1500)),
+	}
+	tl := PerSecond(events, ms(1500))
+	// Second 1: two keys in one second. Second 2: three keys in half a
+	// second, five keys and no standing error after 1.5s.
+	want := []struct {
+		wpm, raw float64
+		errors   int
+	}{
+		{24, 24, 0},
+		{40, 72, 1},
+	}
+	if len(tl.WPM) != len(want) || len(tl.Raw) != len(want) || len(tl.Errors) != len(want) {
+		t.Fatalf("timeline = %+v, want %d seconds", tl, len(want))
+	}
+	for i, w := range want {
+		if !near(tl.WPM[i], w.wpm) || !near(tl.Raw[i], w.raw) || tl.Errors[i] != w.errors {
+			t.Fatalf("second %d = %.2f/%.2f/%d, want %.2f/%.2f/%d", i+1, tl.WPM[i], tl.Raw[i], tl.Errors[i], w.wpm, w.raw, w.errors)
+		}
+	}
+	if tl := PerSecond(events, 0); len(tl.WPM) != 0 {
+		t.Fatalf("PerSecond(0) = %+v, want empty", tl)
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	cases := []struct {
+		name     string
+		events   []engine.Event
+		prompts  []string
+		finished bool
+		want     Chars
+	}{
+		{
+			name: "finished single prompt",
+			events: []engine.Event{
+				{Kind: engine.KindCorrect, Pos: 0},
+				{Kind: engine.KindIncorrect, Pos: 1},
+				{Kind: engine.KindExtra, Pos: 2},
+				{Kind: engine.KindBackspace, Pos: 1},
+				{Kind: engine.KindCorrect, Pos: 1},
+			},
+			prompts:  []string{"ab"},
+			finished: true,
+			want:     Chars{Correct: 2, Extra: 1},
+		},
+		{
+			name: "moved past prompts count missed",
+			events: []engine.Event{
+				{Kind: engine.KindCorrect, Prompt: 0, Pos: 0},
+				{Kind: engine.KindIncorrect, Prompt: 0, Pos: 1},
+				{Kind: engine.KindCorrect, Prompt: 1, Pos: 0},
+			},
+			prompts: []string{"abcd", "efg"},
+			want:    Chars{Correct: 2, Incorrect: 1, Missed: 2},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Breakdown(c.events, c.prompts, c.finished); got != c.want {
+				t.Fatalf("Breakdown = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestComputeMatchesSession(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := engine.New("hello world", nil, engine.Options{})
+	for i, r := range "helo\bworx\bld" {
+		at := t0.Add(time.Duration(i) * 100 * time.Millisecond)
+		if r == '\b' {
+			s.Backspace(at)
+			continue
+		}
+		s.Type(r, at)
+	}
+	snap := s.Snapshot()
+	got := Compute(s.Events(), time.Minute)
+	// Uncorrected errors are exactly the wrong characters left in the input.
+	if got.Keystrokes != 10 || got.Uncorrected != len(snap.Input)-snap.Correct {
+		t.Fatalf("Compute = %+v for input %q (correct %d)", got, string(snap.Input), snap.Correct)
+	}
+}
@@ -0,0 +1,379 @@
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Language is a programming language of the code mode. Its sanitizer
+// strips the comments and boilerplate that are not worth typing.
+type Language struct {
+	// Name is the key of the language in code_examples and code_endpoints.
+	Name  string
+	Label string
+
+	lineComments  []string
+	blockComments [][2]string
+	// header reports whether a line is boilerplate such as an import. If
+	// the boilerplate continues on the following lines, end reports the
+	// line that closes it.
+	header func(l string) (drop bool, end func(string) bool)
+}
+
+var languages = []Language{
+	{
+		Name:          "go",
+		Label:         "Go",
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		header: func(l string) (bool, func(string) bool) {
+			switch {
+			case strings.HasPrefix(l, "import ("):
+				return true, lineIs(")")
+			case strings.HasPrefix(l, "package "), strings.HasPrefix(l, "import "):
+				return true, nil
+			}
+			return false, nil
+		},
+	},
+	{
+		Name:          "python",
+		Label:         "Python",
+		lineComments:  []string{"#"},
+		blockComments: [][2]string{{`"""`, `"""`}, {"'''", "'''"}},
+		header: func(l string) (bool, func(string) bool) {
+			if !strings.HasPrefix(l, "import ") && !strings.HasPrefix(l, "from ") {
+				return false, nil
+			}
+			if strings.HasSuffix(l, "(") {
+				return true, lineEndsWith(")")
+			}
+			return true, nil
+		},
+	},
+	{
+		Name:          "javascript",
+		Label:         "JavaScript",
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		header:        jsHeader,
+	},
+	{
+		Name:          "typescript",
+		Label:         "TypeScript",
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		header:        jsHeader,
+	},
+	{
+		Name:          "rust",
+		Label:         "Rust",
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		header: func(l string) (bool, func(string) bool) {
+			switch {
+			case strings.HasPrefix(l, "use "), strings.HasPrefix(l, "pub use "):
+				if strings.HasSuffix(l, ";") {
+					return true, nil
+				}
+				return true, lineEndsWith(";")
+			case strings.HasPrefix(l, "extern crate "), strings.HasPrefix(l, "#!["),
+				strings.HasPrefix(l, "mod ") && strings.HasSuffix(l, ";"):
+				return true, nil
+			}
+			return false, nil
+		},
+	},
+	{
+		Name:          "sql",
+		Label:         "SQL",
+		lineComments:  []string{"--"},
+		blockComments: [][2]string{{"/*", "*/"}},
+	},
+	{
+		Name:         "shell",
+		Label:        "Shell",
+		lineComments: []string{"#"},
+		header: func(l string) (bool, func(string) bool) {
+			return strings.HasPrefix(l, "set -"), nil
+		},
+	},
+}
+
+// jsHeader drops imports, CommonJS requires and "use strict".
+func jsHeader(l string) (bool, func(string) bool) {
+	switch {
+	case l == `"use strict";`, l == `'use strict';`, l == `"use strict"`, l == `'use strict'`:
+		return true, nil
+	case strings.HasPrefix(l, "import "):
+		if strings.Contains(l, " from ") || strings.HasSuffix(l, ";") ||
+			strings.HasPrefix(l, `import "`) || strings.HasPrefix(l, "import '") {
+			return true, nil
+		}
+		return true, func(l string) bool { return strings.Contains(l, " from ") || strings.HasPrefix(l, "from ") }
+	case strings.HasPrefix(l, "export ") && strings.Contains(l, " from "):
+		return true, nil
+	case strings.Contains(l, "= require("):
+		return true, nil
+	}
+	return false, nil
+}
+
+func lineIs(s string) func(string) bool {
+	return func(l string) bool { return l == s }
+}
+
+func lineEndsWith(s string) func(string) bool {
+	return func(l string) bool { return strings.HasSuffix(l, s) }
+}
+
+// Languages returns the languages of the code mode in menu order; the
+// first is the default.
+func Languages() []Language {
+	return append([]Language(nil), languages...)
+}
+
+// LanguageByName looks a language up by its Name.
+func LanguageByName(name string) (Language, bool) {
+	for _, l := range languages {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Language{}, false
+}
+
+// Code prompts are cut at a line boundary to fit a screen; maxCodeLen is
+// in bytes.
+const (
+	maxCodeLines = 12
+	maxCodeLen   = 320
+)
+
+// Clean strips the comments, blank lines and boilerplate of the language
+// from source code. Line breaks and indentation are kept, with tabs
+// expanded to four spaces and the common indentation removed.
+func (lang Language) Clean(raw string) string {
+	codeLines := lang.codeLines(raw)
+	if len(codeLines) == 0 {
+		return ""
+	}
+	if first := codeLines[0]; len(first) > maxCodeLen {
+		first = first[:maxCodeLen]
+		if i := strings.LastIndex(first, " "); i > 80 {
+			first = first[:i]
+		}
+		return strings.TrimSpace(first)
+	}
+	n, size := 1, len(codeLines[0])
+	for n < len(codeLines) && n < maxCodeLines && size+1+len(codeLines[n]) <= maxCodeLen {
+		size += 1 + len(codeLines[n])
+		n++
+	}
+	return strings.Join(codeLines[:n], "\n")
+}
+
+// codeLines returns the dedented lines Clean keeps, before the cut.
+func (lang Language) codeLines(raw string) []string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	codeLines := make([]string, 0, len(lines))
+	// skipUntil is set while inside a block comment or a multi-line header.
+	var skipUntil func(string) bool
+
+lines:
+	for _, line := range lines {
+		l := strings.TrimSpace(line)
+		if l == "" {
+			continue
+		}
+		if skipUntil != nil {
+			if skipUntil(l) {
+				skipUntil = nil
+			}
+			continue
+		}
+		for _, bc := range lang.blockComments {
+			if strings.HasPrefix(l, bc[0]) {
+				if !strings.Contains(l[len(bc[0]):], bc[1]) {
+					closer := bc[1]
+					skipUntil = func(l string) bool { return strings.Contains(l, closer) }
+				}
+				continue lines
+			}
+		}
+		for _, c := range lang.lineComments {
+			if strings.HasPrefix(l, c) {
+				continue lines
+			}
+		}
+		if lang.header != nil {
+			if drop, end := lang.header(l); drop {
+				skipUntil = end
+				continue
+			}
+		}
+		codeLines = append(codeLines, expandTabs(line))
+	}
+
+	if len(codeLines) > 0 {
+		dedent(codeLines)
+	}
+	return codeLines
+}
+
+// expandTabs replaces the tabs of line with four spaces, which is how code
+// prompts are indented, and drops trailing whitespace.
+func expandTabs(line string) string {
+	return strings.TrimRightFunc(strings.ReplaceAll(line, "\t", "    "), unicode.IsSpace)
+}
+
+// tidyExample indents a configured example like Clean does fetched code:
+// tabs are expanded, blank lines dropped and the common indentation
+// removed. Comments are kept.
+func tidyExample(example string) string {
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(example, "\r\n", "\n"), "\n") {
+		if l = expandTabs(l); strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	dedent(lines)
+	return strings.Join(lines, "\n")
+}
+
+// dedent removes the leading spaces all lines share.
+func dedent(lines []string) {
+	common := -1
+	for _, l := range lines {
+		indent := len(l) - len(strings.TrimLeft(l, " "))
+		if common < 0 || indent < common {
+			common = indent
+		}
+	}
+	for i, l := range lines {
+		lines[i] = l[common:]
+	}
+}
+
+type codeResponse struct {
+	Content string `json:"content"`
+	Code    string `json:"code"`
+	Text    string `json:"text"`
+}
+
+// codeProvider serves snippets of the Service's current language, from
+// the language's endpoint if one is configured and otherwise from its
+// configured examples.
+type codeProvider struct {
+	endpoints map[string]string
+	examples  map[string][]string
+	language  func() Language
+	do        func(*http.Request) (*http.Response, error)
+	intn      func(int) int
+	backoffs  map[string]*backoff
+}
+
+func (p *codeProvider) Name() string  { return "code" }
+func (p *codeProvider) Label() string { return "Code Practice" }
+
+func (p *codeProvider) Bind(env Env) Provider {
+	backoffs := make(map[string]*backoff, len(languages))
+	for _, l := range languages {
+		backoffs[l.Name] = &backoff{}
+	}
+	// Tab types nothing during a test, so examples must not hold tabs.
+	examples := make(map[string][]string, len(env.Config.CodeExamples))
+	for lang, exs := range env.Config.CodeExamples {
+		for _, ex := range exs {
+			if ex = tidyExample(ex); ex != "" {
+				examples[lang] = append(examples[lang], ex)
+			}
+		}
+	}
+	return &codeProvider{
+		endpoints: env.Config.CodeEndpoints,
+		examples:  examples,
+		language:  env.language,
+		do:        env.Do,
+		intn:      env.Intn,
+		backoffs:  backoffs,
+	}
+}
+
+func (p *codeProvider) lang() Language {
+	if p.language == nil {
+		return languages[0]
+	}
+	return p.language()
+}
+
+func (p *codeProvider) Remote() bool {
+	return strings.TrimSpace(p.endpoints[p.lang().Name]) != "" && p.do != nil
+}
+
+func (p *codeProvider) Next(ctx context.Context, previous string) (string, error) {
+	lang := p.lang()
+	endpoint := strings.TrimSpace(p.endpoints[lang.Name])
+	if endpoint == "" || p.do == nil {
+		return "", fmt.Errorf("%s example endpoint is empty", lang.Name)
+	}
+	return fetchRemote(ctx, previous, p.backoffs[lang.Name], func(ctx context.Context) (string, error) {
+		return p.fetchCode(ctx, lang, endpoint)
+	})
+}
+
+func (p *codeProvider) Fallback(previous string) string {
+	if p.intn == nil {
+		return `fmt.Println("hello, tuiper")`
+	}
+	return pickDifferent(p.intn, p.examples[p.lang().Name], previous, `fmt.Println("hello, tuiper")`)
+}
+
+func (p *codeProvider) fetchCode(ctx context.Context, lang Language, endpoint string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json, text/plain;q=0.9")
+
+	resp, err := p.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s example API status %d", lang.Name, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var payload codeResponse
+	if err := json.Unmarshal(body, &payload); err == nil {
+		for _, c := range []string{lang.Clean(payload.Content), lang.Clean(payload.Code), lang.Clean(payload.Text)} {
+			if c != "" {
+				return c, nil
+			}
+		}
+	}
+
+	plain := lang.Clean(string(body))
+	if plain == "" {
+		return "", fmt.Errorf("%s example payload is empty", lang.Name)
+	}
+	return plain, nil
+}
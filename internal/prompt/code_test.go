@@ -0,0 +1,99 @@
+package prompt
+
+import (
+	"testing"
+
+	"tuitype/internal/config"
+)
+
+func TestLanguageClean(t *testing.T) {
+	cases := []struct {
+		lang string
+		raw  string
+		want string
+	}{
+		{
+			lang: "python",
+			raw:  "#!/usr/bin/env python3\n\"\"\"Module doc.\n\nMore.\n\"\"\"\nimport os\nfrom typing import (\n    List,\n)\n\n# helper\ndef f(xs):\n    return [x for x in xs]\n",
+			want: "def f(xs):\n    return [x for x in xs]",
+		},
+		{
+			lang: "javascript",
+			raw:  "'use strict';\nimport {\n  a,\n  b,\n} from './x.js';\nconst fs = require('fs');\n/** doc */\nexport function f() {\n  return a + b;\n}\n",
+			want: "export function f() {\n  return a + b;\n}",
+		},
+		{
+			lang: "typescript",
+			raw:  "import type { User } from \"./user\";\n/// <reference types=\"node\" />\nconst n: number = 1;\n",
+			want: "const n: number = 1;",
+		},
+		{
+			lang: "rust",
+			raw:  "#![allow(dead_code)]\nuse std::{\n    fmt,\n    io,\n};\nmod util;\n// add\nfn add(a: i32, b: i32) -> i32 {\n    a + b\n}\n",
+			want: "fn add(a: i32, b: i32) -> i32 {\n    a + b\n}",
+		},
+		{
+			lang: "sql",
+			raw:  "-- users\n/* all\n   of them */\nSELECT *\n  FROM users;\n",
+			want: "SELECT *\n  FROM users;",
+		},
+		{
+			lang: "shell",
+			raw:  "#!/bin/sh\nset -eu\n# loop\nfor f in *; do\n\techo \"$f\"\ndone\n",
+			want: "for f in *; do\n    echo \"$f\"\ndone",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.lang, func(t *testing.T) {
+			lang, ok := LanguageByName(c.lang)
+			if !ok {
+				t.Fatalf("LanguageByName(%q) not found", c.lang)
+			}
+			if got := lang.Clean(c.raw); got != c.want {
+				t.Fatalf("Clean = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestLanguagesMatchConfig(t *testing.T) {
+	langs := Languages()
+	if len(langs) != len(config.CodeLanguages) {
+		t.Fatalf("%d languages, config knows %d", len(langs), len(config.CodeLanguages))
+	}
+	for i, name := range config.CodeLanguages {
+		if langs[i].Name != name {
+			t.Fatalf("language %d = %q, config has %q", i, langs[i].Name, name)
+		}
+	}
+}
+
+func TestCodeModeUsesSelectedLanguage(t *testing.T) {
+	s := testService()
+	if got := s.Language().Name; got != "go" {
+		t.Fatalf("default language = %q, want go", got)
+	}
+	if s.SetLanguage("cobol") {
+		t.Fatal("SetLanguage accepted an unknown language")
+	}
+	if !s.SetLanguage("python") {
+		t.Fatal("SetLanguage(python) = false")
+	}
+	if got := s.Next(ModeCode, ""); got != `print("a")` && got != `print("b")` {
+		t.Fatalf("python prompt = %q", got)
+	}
+}
+
+func TestConfiguredExamplesHaveNoTabs(t *testing.T) {
+	s := New(Config{
+		Words:            []string{"a"},
+		SpecialCharWords: []string{"!"},
+		PromptWordCount:  1,
+		CodeExamples: map[string][]string{
+			"go": {"\tif x {\n\t\ty()\n\n\t}  "},
+		},
+	})
+	if got, want := s.Next(ModeCode, ""), "if x {\n    y()\n}"; got != want {
+		t.Fatalf("prompt = %q, want %q", got, want)
+	}
+}
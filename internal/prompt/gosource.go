@@ -0,0 +1,175 @@
+package prompt
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Limits of GoSnippets: files larger than maxSourceSize are skipped and
+// the walk stops once maxSnippets are collected. Shorter snippets than
+// minSnippetLen bytes are not worth a prompt.
+const (
+	maxSourceSize = 1 << 20
+	maxSnippets   = 2000
+	minSnippetLen = 30
+)
+
+// GoSnippets walks the Go files under dir and extracts code of a typeable
+// size: whole functions, block statements (if, for, switch, select) and
+// statements built around a keyed composite literal such as a struct
+// literal. Snippets are cleaned like the Go examples of the code mode and
+// only kept if they fit a prompt uncut. Vendored, testdata, hidden and
+// generated files are skipped, as are files that do not parse.
+func GoSnippets(dir string) ([]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	lang, _ := LanguageByName("go")
+	seen := map[string]bool{}
+	var snippets []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "vendor" || name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxSourceSize {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, s := range goFileSnippets(lang, src) {
+			if !seen[s] {
+				seen[s] = true
+				snippets = append(snippets, s)
+			}
+		}
+		if len(snippets) >= maxSnippets {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(snippets) == 0 {
+		return nil, fmt.Errorf("no Go snippets of a typeable size in %s", dir)
+	}
+	return snippets, nil
+}
+
+// goFileSnippets extracts the snippets of one source file.
+func goFileSnippets(lang Language, src []byte) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil || ast.IsGenerated(file) {
+		return nil
+	}
+	tf := fset.File(file.Pos())
+
+	var snippets []string
+	add := func(n ast.Node) {
+		start, end := tf.Offset(n.Pos()), tf.Offset(n.End())
+		// Start at the beginning of the line so the first line keeps its
+		// indentation relative to the rest; skip nodes that share their
+		// first line with other code.
+		lineStart := tf.Offset(tf.LineStart(tf.Line(n.Pos())))
+		if strings.TrimSpace(string(src[lineStart:start])) != "" {
+			return
+		}
+		if s, ok := fitSnippet(lang, string(src[lineStart:end])); ok {
+			snippets = append(snippets, s)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Body != nil {
+				add(decl)
+			}
+		case *ast.GenDecl:
+			if decl.Tok == token.VAR && hasKeyedLiteral(decl) {
+				add(decl)
+			}
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		block, ok := n.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		for _, stmt := range block.List {
+			switch stmt.(type) {
+			case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt,
+				*ast.TypeSwitchStmt, *ast.SelectStmt:
+				add(stmt)
+			case *ast.AssignStmt, *ast.ReturnStmt, *ast.DeclStmt, *ast.ExprStmt:
+				if hasKeyedLiteral(stmt) {
+					add(stmt)
+				}
+			}
+		}
+		return true
+	})
+	return snippets
+}
+
+// hasKeyedLiteral reports whether n contains a composite literal with
+// key: value elements, such as a struct literal.
+func hasKeyedLiteral(n ast.Node) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.CompositeLit); ok && len(lit.Elts) > 0 {
+			if _, ok := lit.Elts[0].(*ast.KeyValueExpr); ok {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// fitSnippet cleans raw and reports whether the result fits a prompt
+// without being cut and can be typed on a plain keyboard.
+func fitSnippet(lang Language, raw string) (string, bool) {
+	lines := lang.codeLines(raw)
+	if len(lines) == 0 || len(lines) > maxCodeLines {
+		return "", false
+	}
+	s := strings.Join(lines, "\n")
+	if len(s) < minSnippetLen || len(s) > maxCodeLen {
+		return "", false
+	}
+	for _, r := range s {
+		if r != '\n' && (r < ' ' || r > '~') {
+			return "", false
+		}
+	}
+	return s, true
+}
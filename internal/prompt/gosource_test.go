@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoSnippets(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", `package a
+
+import "fmt"
+
+// Greet says hello.
+func Greet(name string) string {
+	// build it
+	return fmt.Sprintf("hello, %s", name)
+}
+
+func run(xs []int) {
+	for _, x := range xs {
+		if x > 0 {
+			fmt.Println("positive", x)
+		}
+	}
+	cfg := Config{Name: "tuiper", Retries: 3}
+	_ = cfg
+	fmt.Println("✓ done")
+}
+
+type Config struct {
+	Name    string
+	Retries int
+}
+`)
+	write("vendor/v/v.go", "package v\n\nfunc Vendored() { println(\"vendored code here\") }\n")
+	write("gen.go", "// Code generated by tool. DO NOT EDIT.\n\npackage a\n\nfunc Generated() { println(\"generated code here\") }\n")
+	write("broken.go", "package a\n\nfunc {")
+	write("small/small.go", "package small\n\nfunc f() {}\n")
+
+	// run is left out whole for its non-ASCII string, and the vendored,
+	// generated and broken files entirely.
+	snippets, err := GoSnippets(dir)
+	if err != nil {
+		t.Fatalf("GoSnippets returned error: %v", err)
+	}
+	want := []string{
+		"func Greet(name string) string {\n    return fmt.Sprintf(\"hello, %s\", name)\n}",
+		"for _, x := range xs {\n    if x > 0 {\n        fmt.Println(\"positive\", x)\n    }\n}",
+		`cfg := Config{Name: "tuiper", Retries: 3}`,
+		"if x > 0 {\n    fmt.Println(\"positive\", x)\n}",
+	}
+	if strings.Join(snippets, "\n\n") != strings.Join(want, "\n\n") {
+		t.Fatalf("snippets =\n%s\n\nwant\n%s", strings.Join(snippets, "\n\n"), strings.Join(want, "\n\n"))
+	}
+
+	if _, err := GoSnippets(filepath.Join(dir, "small")); err == nil {
+		t.Fatal("expected an error for a tree without snippets")
+	}
+	if _, err := GoSnippets(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
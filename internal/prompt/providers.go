@@ -0,0 +1,246 @@
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+func builtinProviders() []Provider {
+	return []Provider{
+		&wordsProvider{
+			name:     "normal",
+			label:    "Normal",
+			pool:     func(c Config) []string { return c.Words },
+			fallback: "the quick brown fox jumps over the lazy dog.",
+		},
+		&wordsProvider{
+			name:     "special",
+			label:    "Special Chars Practice",
+			pool:     func(c Config) []string { return c.SpecialCharWords },
+			fallback: "!@#$ %^&* ()_+ []{} <>? /\\| `~ ;;:: ++--.",
+		},
+		&quoteProvider{},
+		&codeProvider{},
+		&wordsProvider{
+			name:  "weak",
+			label: "Weak Keys",
+			pool: func(c Config) []string {
+				return append(append([]string(nil), c.Words...), c.SpecialCharWords...)
+			},
+			fallback: "the quick brown fox jumps over the lazy dog.",
+			adaptive: true,
+		},
+		&textProvider{},
+	}
+}
+
+// wordsProvider joins randomly sampled words from a configured pool. An
+// adaptive provider weights the sampling toward the Service's Weakness.
+type wordsProvider struct {
+	name     string
+	label    string
+	pool     func(Config) []string
+	fallback string
+	adaptive bool
+
+	words    []string
+	count    int
+	intn     func(int) int
+	weakness func() Weakness
+}
+
+func (p *wordsProvider) Name() string  { return p.name }
+func (p *wordsProvider) Label() string { return p.label }
+
+func (p *wordsProvider) Bind(env Env) Provider {
+	return &wordsProvider{
+		name:     p.name,
+		label:    p.label,
+		pool:     p.pool,
+		fallback: p.fallback,
+		adaptive: p.adaptive,
+		words:    p.pool(env.Config),
+		count:    env.Config.PromptWordCount,
+		intn:     env.Intn,
+		weakness: env.Weakness,
+	}
+}
+
+func (p *wordsProvider) Next(_ context.Context, previous string) (string, error) {
+	if len(p.words) == 0 || p.count <= 0 || p.intn == nil {
+		return p.fallback, nil
+	}
+	pick := func() string { return p.words[p.intn(len(p.words))] }
+	if p.adaptive {
+		w := p.weakness()
+		weights := make([]float64, len(p.words))
+		for i, word := range p.words {
+			weights[i] = 1 + w.Score(word)
+		}
+		pick = func() string { return p.words[pickWeighted(p.intn, weights)] }
+	}
+	for i := 0; i < 8; i++ {
+		buf := make([]string, p.count)
+		for j := range buf {
+			buf[j] = pick()
+		}
+		s := strings.Join(buf, " ") + "."
+		if s != previous {
+			return s, nil
+		}
+	}
+	return p.fallback, nil
+}
+
+func pickDifferent(intn func(int) int, options []string, previous, emptyFallback string) string {
+	if len(options) == 0 {
+		return emptyFallback
+	}
+	if len(options) == 1 {
+		return options[0]
+	}
+	for i := 0; i < 8; i++ {
+		c := options[intn(len(options))]
+		if c != previous {
+			return c
+		}
+	}
+	for _, c := range options {
+		if c != previous {
+			return c
+		}
+	}
+	return options[0]
+}
+
+// backoff pauses a remote endpoint after repeated failures.
+type backoff struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (b *backoff) active() (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.until, time.Now().Before(b.until)
+}
+
+func (b *backoff) trip(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.until = time.Now().Add(d)
+}
+
+// fetchRemote retries fetch up to three times, skipping results equal to
+// previous, and backs the endpoint off when every attempt failed.
+func fetchRemote(ctx context.Context, previous string, b *backoff, fetch func(context.Context) (string, error)) (string, error) {
+	if until, ok := b.active(); ok {
+		return "", fmt.Errorf("endpoint backing off until %s", until.Format(time.TimeOnly))
+	}
+
+	var lastErr error
+	for i := 0; i < 3; i++ {
+		p, err := fetch(ctx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if p != previous {
+			return p, nil
+		}
+	}
+	if lastErr != nil {
+		b.trip(15 * time.Second)
+		return "", lastErr
+	}
+	return "", fmt.Errorf("endpoint repeated the previous prompt")
+}
+
+type quoteResponse struct {
+	Content string `json:"content"`
+	Quote   string `json:"quote"`
+	Text    string `json:"text"`
+}
+
+// quoteProvider fetches quotes from a JSON endpoint.
+type quoteProvider struct {
+	endpoint  string
+	do        func(*http.Request) (*http.Response, error)
+	intn      func(int) int
+	backoff   *backoff
+	fallbacks []string
+}
+
+func (p *quoteProvider) Name() string  { return "quote" }
+func (p *quoteProvider) Label() string { return "Quote Practice" }
+
+func (p *quoteProvider) Bind(env Env) Provider {
+	return &quoteProvider{
+		endpoint: strings.TrimSpace(env.Config.QuoteEndpoint),
+		do:       env.Do,
+		intn:     env.Intn,
+		backoff:  &backoff{},
+		fallbacks: []string{
+			"Type with calm precision and let rhythm do the heavy lifting.",
+			"Progress in typing is consistency repeated over short focused sessions.",
+			"Accuracy builds speed; speed without accuracy always stalls.",
+		},
+	}
+}
+
+func (p *quoteProvider) Remote() bool { return p.endpoint != "" && p.do != nil }
+
+func (p *quoteProvider) Next(ctx context.Context, previous string) (string, error) {
+	if !p.Remote() {
+		return "", fmt.Errorf("quote endpoint is empty")
+	}
+	return fetchRemote(ctx, previous, p.backoff, p.fetchQuote)
+}
+
+func (p *quoteProvider) Fallback(previous string) string {
+	if p.intn == nil {
+		return "keep typing with steady rhythm."
+	}
+	return pickDifferent(p.intn, p.fallbacks, previous, "keep typing with steady rhythm.")
+}
+
+func (p *quoteProvider) fetchQuote(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 1200*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("quote API status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	var payload quoteResponse
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	for _, c := range []string{strings.TrimSpace(payload.Content), strings.TrimSpace(payload.Quote), strings.TrimSpace(payload.Text)} {
+		if c != "" {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("quote API returned empty payload")
+}
@@ -0,0 +1,110 @@
+package prompt
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type staticProvider struct {
+	name, label string
+	intn        func(int) int
+}
+
+func (p *staticProvider) Name() string  { return p.name }
+func (p *staticProvider) Label() string { return p.label }
+
+func (p *staticProvider) Bind(env Env) Provider {
+	return &staticProvider{name: p.name, label: p.label, intn: env.Intn}
+}
+
+func (p *staticProvider) Next(context.Context, string) (string, error) {
+	if p.intn == nil {
+		return "unbound", nil
+	}
+	return "internal docs " + string(rune('a'+p.intn(26))), nil
+}
+
+func TestBuiltinModesKeepTheirOrder(t *testing.T) {
+	want := []string{"normal", "special", "quote", "code", "weak", "text"}
+	for i, name := range want {
+		if got := Mode(i).Name(); got != name {
+			t.Fatalf("Mode(%d).Name() = %q, want %q", i, got, name)
+		}
+		if m, ok := ModeByName(name); !ok || m != Mode(i) {
+			t.Fatalf("ModeByName(%q) = %d, %v", name, m, ok)
+		}
+	}
+	if got := ModeLabels()[ModeCode]; got != "Code Practice" {
+		t.Fatalf("ModeLabels()[ModeCode] = %q", got)
+	}
+}
+
+func TestRegisterAddsBoundProvider(t *testing.T) {
+	mode := Register(&staticProvider{name: "internal-docs", label: "Internal Docs"})
+	labels := ModeLabels()
+	if int(mode) != len(labels)-1 || labels[mode] != "Internal Docs" {
+		t.Fatalf("registered mode %d, labels %q", mode, labels)
+	}
+	if m, ok := ModeByName("internal-docs"); !ok || m != mode {
+		t.Fatalf("ModeByName(internal-docs) = %d, %v", m, ok)
+	}
+
+	s := testService()
+	s.Reseed(7)
+	got := s.Next(mode, "")
+	if got == "unbound" || got == "" {
+		t.Fatalf("Next = %q, want output of the bound provider", got)
+	}
+	if s.Remote(mode) {
+		t.Fatal("local provider reported as remote")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on duplicate registration")
+		}
+	}()
+	Register(&staticProvider{name: "internal-docs"})
+}
+
+func TestWeakModeFavorsWeakWords(t *testing.T) {
+	s := New(Config{
+		Words:            []string{"alpha", "beta", "gamma"},
+		SpecialCharWords: []string{"{}", "||"},
+		PromptWordCount:  200,
+		Seed:             7,
+	})
+	count := func() map[string]int {
+		n := map[string]int{}
+		for _, w := range strings.Fields(strings.TrimSuffix(s.Next(ModeWeak, ""), ".")) {
+			n[w]++
+		}
+		return n
+	}
+
+	uniform := count()
+	if len(uniform) != 5 {
+		t.Fatalf("uniform weak prompt used %d distinct words, want all 5 from both pools", len(uniform))
+	}
+
+	s.SetWeakness(Weakness{
+		Chars:   map[rune]float64{'{': 10},
+		Bigrams: map[[2]rune]float64{{'m', 'm'}: 10},
+	})
+	weighted := count()
+	// "{}" and "gamma" weigh 11 against 1 for each other word.
+	if weighted["{}"] < 80 || weighted["gamma"] < 80 {
+		t.Fatalf("weighted counts = %v, want {} and gamma dominating", weighted)
+	}
+}
+
+func TestWeaknessScore(t *testing.T) {
+	w := Weakness{Chars: map[rune]float64{'a': 1, '|': 2}, Bigrams: map[[2]rune]float64{{'a', 'b'}: 0.5}}
+	cases := map[string]float64{"": 0, "xyz": 0, "aab": 2.5, "||": 4}
+	for word, want := range cases {
+		if got := w.Score(word); got != want {
+			t.Fatalf("Score(%q) = %v, want %v", word, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package prompt
+
+// Queue buffers prefetched prompts per mode. It is not safe for concurrent
+// use: the owner claims fetch slots, runs the fetches wherever it likes and
+// reports each result back with Push or Drop.
+type Queue struct {
+	size    int
+	ready   map[Mode][]string
+	pending map[Mode]int
+}
+
+func NewQueue(size int) *Queue {
+	if size < 1 {
+		size = 1
+	}
+	return &Queue{
+		size:    size,
+		ready:   map[Mode][]string{},
+		pending: map[Mode]int{},
+	}
+}
+
+// Pop takes the oldest prefetched prompt for mode, skipping one equal to
+// previous.
+func (q *Queue) Pop(mode Mode, previous string) (string, bool) {
+	ready := q.ready[mode]
+	for len(ready) > 0 {
+		p := ready[0]
+		ready = ready[1:]
+		if p != previous {
+			q.ready[mode] = ready
+			return p, true
+		}
+	}
+	q.ready[mode] = ready
+	return "", false
+}
+
+// Claim reserves and returns the number of fetches needed to refill the
+// queue for mode, counting fetches already in flight.
+func (q *Queue) Claim(mode Mode) int {
+	n := q.size - len(q.ready[mode]) - q.pending[mode]
+	if n < 0 {
+		return 0
+	}
+	q.pending[mode] += n
+	return n
+}
+
+// Push completes a claimed fetch with its prompt. Duplicates of prompts
+// already queued are dropped.
+func (q *Queue) Push(mode Mode, p string) {
+	q.Drop(mode)
+	for _, have := range q.ready[mode] {
+		if have == p {
+			return
+		}
+	}
+	q.ready[mode] = append(q.ready[mode], p)
+}
+
+// Drop completes a claimed fetch that produced nothing.
+func (q *Queue) Drop(mode Mode) {
+	if q.pending[mode] > 0 {
+		q.pending[mode]--
+	}
+}
+
+// Reset discards the prompts ready for mode, e.g. once they no longer
+// match its settings. Fetches in flight still complete with Push or Drop.
+func (q *Queue) Reset(mode Mode) {
+	delete(q.ready, mode)
+}
+
+// Len is the number of prompts ready for mode.
+func (q *Queue) Len(mode Mode) int {
+	return len(q.ready[mode])
+}
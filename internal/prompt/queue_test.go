@@ -0,0 +1,38 @@
+package prompt
+
+import "testing"
+
+func TestQueueClaimCountsInFlight(t *testing.T) {
+	q := NewQueue(3)
+	if n := q.Claim(ModeQuote); n != 3 {
+		t.Fatalf("first Claim = %d, want 3", n)
+	}
+	if n := q.Claim(ModeQuote); n != 0 {
+		t.Fatalf("Claim with fetches in flight = %d, want 0", n)
+	}
+	q.Push(ModeQuote, "a")
+	q.Drop(ModeQuote)
+	if n := q.Claim(ModeQuote); n != 1 {
+		t.Fatalf("Claim after one push and one drop = %d, want 1", n)
+	}
+	if n := q.Claim(ModeCode); n != 3 {
+		t.Fatalf("Claim for another mode = %d, want 3", n)
+	}
+}
+
+func TestQueuePopSkipsPrevious(t *testing.T) {
+	q := NewQueue(3)
+	q.Claim(ModeQuote)
+	q.Push(ModeQuote, "a")
+	q.Push(ModeQuote, "a")
+	q.Push(ModeQuote, "b")
+	if q.Len(ModeQuote) != 2 {
+		t.Fatalf("Len = %d, want 2 after duplicate push", q.Len(ModeQuote))
+	}
+	if p, ok := q.Pop(ModeQuote, "a"); !ok || p != "b" {
+		t.Fatalf("Pop = %q, %v, want b", p, ok)
+	}
+	if _, ok := q.Pop(ModeQuote, ""); ok {
+		t.Fatal("expected empty queue")
+	}
+}
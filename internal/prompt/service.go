@@ -2,15 +2,15 @@ package prompt
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
-	"strings"
+	"sync"
 	"time"
 )
 
+// Mode indexes the provider registry. The built-in modes are registered
+// first, in this order.
 type Mode int
 
 const (
@@ -18,299 +18,322 @@ const (
 	ModeSpecialChars
 	ModeQuote
 	ModeCode
+	ModeWeak
+	ModeText
 )
 
-var modeLabels = []string{
-	"Normal",
-	"Special Chars Practice",
-	"Quote Practice",
-	"Code Practice",
+// Provider is a source of typing prompts. Next returns the prompt that
+// follows previous; it should avoid repeating previous where possible.
+type Provider interface {
+	// Name is the stable identifier persisted in history and test codes.
+	Name() string
+	// Label is shown in the mode menu.
+	Label() string
+	Next(ctx context.Context, previous string) (string, error)
 }
 
-func ModeLabels() []string {
-	return append([]string(nil), modeLabels...)
+// RemoteProvider is a Provider whose Next may block on the network. The UI
+// runs Next in the background and uses Fallback when nothing is ready.
+type RemoteProvider interface {
+	Provider
+	// Remote reports whether Next actually goes to the network, e.g.
+	// false when the endpoint is not configured.
+	Remote() bool
+	// Fallback returns a local prompt without blocking.
+	Fallback(previous string) string
 }
 
-type Config struct {
-	Words             []string
-	SpecialCharWords  []string
-	PromptWordCount   int
-	QuoteEndpoint     string
-	GoExampleEndpoint string
-	GoExamples        []string
+// Binder is implemented by providers that need the Service configuration,
+// its seeded random source or its HTTP client. Each Service binds its own
+// copy of every registered provider.
+type Binder interface {
+	Bind(env Env) Provider
 }
 
-type Service struct {
-	cfg               Config
-	client            *http.Client
-	rng               *rand.Rand
-	quoteBackoffUntil time.Time
-	codeBackoffUntil  time.Time
-	fallbackQuotes    []string
+// Env is what a Service offers to providers implementing Binder.
+type Env struct {
+	Config Config
+	svc    *Service
 }
 
-func New(cfg Config) *Service {
-	return &Service{
-		cfg: Config{
-			Words:             append([]string(nil), cfg.Words...),
-			SpecialCharWords:  append([]string(nil), cfg.SpecialCharWords...),
-			PromptWordCount:   cfg.PromptWordCount,
-			QuoteEndpoint:     cfg.QuoteEndpoint,
-			GoExampleEndpoint: cfg.GoExampleEndpoint,
-			GoExamples:        append([]string(nil), cfg.GoExamples...),
-		},
-		client: &http.Client{Timeout: 1200 * time.Millisecond},
-		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
-		fallbackQuotes: []string{
-			"Type with calm precision and let rhythm do the heavy lifting.",
-			"Progress in typing is consistency repeated over short focused sessions.",
-			"Accuracy builds speed; speed without accuracy always stalls.",
-		},
-	}
+// Intn draws from the Service's seeded random source, so providers that
+// use it produce reproducible prompts.
+func (e Env) Intn(n int) int {
+	return e.svc.intn(n)
 }
 
-func (s *Service) Next(mode Mode, previous string) string {
-	switch mode {
-	case ModeQuote:
-		return s.nextQuote(previous)
-	case ModeCode:
-		return s.nextCode(previous)
-	case ModeSpecialChars:
-		return s.nextFromWords(previous, s.cfg.SpecialCharWords, "!@#$ %^&* ()_+ []{} <>? /\\| `~ ;;:: ++--.")
-	default:
-		return s.nextFromWords(previous, s.cfg.Words, "the quick brown fox jumps over the lazy dog.")
-	}
+// Weakness returns the weights last passed to Service.SetWeakness.
+func (e Env) Weakness() Weakness {
+	e.svc.mu.Lock()
+	defer e.svc.mu.Unlock()
+	return e.svc.weakness
 }
 
-func (s *Service) nextFromWords(previous string, words []string, fallback string) string {
-	for i := 0; i < 8; i++ {
-		buf := make([]string, s.cfg.PromptWordCount)
-		for j := range buf {
-			buf[j] = words[s.rng.Intn(len(words))]
-		}
-		p := strings.Join(buf, " ") + "."
-		if p != previous {
-			return p
+// nextChunk returns the custom text chunk at the cursor and moves the
+// cursor on, wrapping around at the end. It reports false if no text is
+// loaded.
+func (e Env) nextChunk() (string, bool) {
+	e.svc.mu.Lock()
+	defer e.svc.mu.Unlock()
+	if len(e.svc.text) == 0 {
+		return "", false
+	}
+	c := e.svc.text[e.svc.textPos]
+	e.svc.textPos = (e.svc.textPos + 1) % len(e.svc.text)
+	return c, true
+}
+
+func (e Env) language() Language {
+	e.svc.mu.Lock()
+	defer e.svc.mu.Unlock()
+	return e.svc.lang
+}
+
+// Do sends req with the Service's HTTP client.
+func (e Env) Do(req *http.Request) (*http.Response, error) {
+	return e.svc.client.Do(req)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = builtinProviders()
+)
+
+// Register adds a provider as a new mode, after the built-in ones. It is
+// meant to be called from an init function, before any Service is
+// created, and panics if the name is empty or already taken.
+func Register(p Provider) Mode {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if p.Name() == "" {
+		panic("prompt: Register with empty provider name")
+	}
+	for _, have := range registry {
+		if have.Name() == p.Name() {
+			panic(fmt.Sprintf("prompt: Register called twice for provider %q", p.Name()))
 		}
 	}
-	return fallback
+	registry = append(registry, p)
+	return Mode(len(registry) - 1)
 }
 
-func pickDifferent(rng *rand.Rand, options []string, previous, emptyFallback string) string {
-	if len(options) == 0 {
-		return emptyFallback
-	}
-	if len(options) == 1 {
-		return options[0]
+func registered() []Provider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Provider(nil), registry...)
+}
+
+// ModeLabels returns the menu label of every registered mode in Mode order.
+func ModeLabels() []string {
+	providers := registered()
+	labels := make([]string, len(providers))
+	for i, p := range providers {
+		labels[i] = p.Label()
 	}
-	for i := 0; i < 8; i++ {
-		c := options[rng.Intn(len(options))]
-		if c != previous {
-			return c
-		}
+	return labels
+}
+
+// Name returns the stable identifier used when persisting a mode.
+func (m Mode) Name() string {
+	providers := registered()
+	if m < 0 || int(m) >= len(providers) {
+		return fmt.Sprintf("mode-%d", int(m))
 	}
-	for _, c := range options {
-		if c != previous {
-			return c
+	return providers[m].Name()
+}
+
+// ModeByName is the inverse of Mode.Name.
+func ModeByName(name string) (Mode, bool) {
+	for i, p := range registered() {
+		if p.Name() == name {
+			return Mode(i), true
 		}
 	}
-	return options[0]
+	return 0, false
 }
 
-type quoteResponse struct {
-	Content string `json:"content"`
-	Quote   string `json:"quote"`
-	Text    string `json:"text"`
+type Config struct {
+	Words            []string
+	SpecialCharWords []string
+	PromptWordCount  int
+	QuoteEndpoint    string
+	// CodeEndpoints and CodeExamples are keyed by Language.Name.
+	CodeEndpoints map[string]string
+	CodeExamples  map[string][]string
+	// Seed makes locally generated prompts reproducible. Zero picks a
+	// random seed.
+	Seed int64
 }
 
-func (s *Service) fetchQuote(endpoint string) (string, error) {
-	if endpoint == "" {
-		return "", fmt.Errorf("quote endpoint is empty")
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), 1200*time.Millisecond)
-	defer cancel()
+// Service is safe for concurrent use, so remote fetches can run in the
+// background while the UI keeps generating local prompts.
+type Service struct {
+	cfg       Config
+	client    *http.Client
+	mu        sync.Mutex
+	rng       *rand.Rand
+	seed      int64
+	weakness  Weakness
+	text      []string
+	textPos   int
+	lang      Language
+	providers []Provider
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Accept", "application/json")
+// NewSeed returns a fresh random seed for Config.Seed or Service.Reseed.
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", err
+func New(cfg Config) *Service {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = NewSeed()
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("quote API status %d", resp.StatusCode)
+	s := &Service{
+		cfg: Config{
+			Words:            append([]string(nil), cfg.Words...),
+			SpecialCharWords: append([]string(nil), cfg.SpecialCharWords...),
+			PromptWordCount:  cfg.PromptWordCount,
+			QuoteEndpoint:    cfg.QuoteEndpoint,
+			CodeEndpoints:    map[string]string{},
+			CodeExamples:     map[string][]string{},
+			Seed:             cfg.Seed,
+		},
+		client: &http.Client{Timeout: 1200 * time.Millisecond},
+		rng:    rand.New(rand.NewSource(seed)),
+		seed:   seed,
+		lang:   languages[0],
 	}
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
-	if err != nil {
-		return "", err
+	for lang, endpoint := range cfg.CodeEndpoints {
+		s.cfg.CodeEndpoints[lang] = endpoint
 	}
-	var payload quoteResponse
-	if err := json.Unmarshal(body, &payload); err != nil {
-		return "", err
+	for lang, examples := range cfg.CodeExamples {
+		s.cfg.CodeExamples[lang] = append([]string(nil), examples...)
 	}
-	for _, c := range []string{strings.TrimSpace(payload.Content), strings.TrimSpace(payload.Quote), strings.TrimSpace(payload.Text)} {
-		if c != "" {
-			return c, nil
+	env := Env{Config: s.cfg, svc: s}
+	for _, p := range registered() {
+		if b, ok := p.(Binder); ok {
+			p = b.Bind(env)
 		}
+		s.providers = append(s.providers, p)
 	}
-	return "", fmt.Errorf("quote API returned empty payload")
+	return s
 }
 
-func (s *Service) nextQuote(previous string) string {
-	pickFallback := func() string {
-		return pickDifferent(s.rng, s.fallbackQuotes, previous, "keep typing with steady rhythm.")
-	}
-	if time.Now().Before(s.quoteBackoffUntil) {
-		return pickFallback()
-	}
-
-	var lastErr error
-	for i := 0; i < 3; i++ {
-		q, err := s.fetchQuote(s.cfg.QuoteEndpoint)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		if q != previous {
-			return q
-		}
-	}
-	if lastErr != nil {
-		s.quoteBackoffUntil = time.Now().Add(15 * time.Second)
-	}
-	return pickFallback()
+// Seed returns the seed the current prompt sequence was generated from.
+func (s *Service) Seed() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seed
 }
 
-type codeResponse struct {
-	Content string `json:"content"`
-	Code    string `json:"code"`
-	Text    string `json:"text"`
+// Reseed restarts the prompt sequence so that the same calls to Next yield
+// the same locally generated prompts. Remote quotes and code examples are
+// not reproducible.
+func (s *Service) Reseed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seed = seed
+	s.rng = rand.New(rand.NewSource(seed))
 }
 
-func cleanGoTypingPrompt(raw string) string {
-	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
-	codeLines := make([]string, 0, len(lines))
-	inBlockComment := false
-	inImportBlock := false
+// SetWeakness replaces the weights of the weak keys mode; w must not be
+// modified afterwards. Callers should refresh it as sessions complete so
+// the drill keeps adapting.
+func (s *Service) SetWeakness(w Weakness) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.weakness = w
+}
 
-	for _, line := range lines {
-		l := strings.TrimSpace(line)
-		if l == "" {
-			continue
-		}
-		if strings.HasPrefix(l, "/*") {
-			inBlockComment = true
-		}
-		if inBlockComment {
-			if strings.Contains(l, "*/") {
-				inBlockComment = false
-			}
-			continue
-		}
-		if strings.HasPrefix(l, "//") || strings.HasPrefix(l, "package ") {
-			continue
-		}
-		if strings.HasPrefix(l, "import (") {
-			inImportBlock = true
-			continue
-		}
-		if inImportBlock {
-			if l == ")" {
-				inImportBlock = false
-			}
-			continue
-		}
-		if strings.HasPrefix(l, "import ") {
-			continue
-		}
-		codeLines = append(codeLines, l)
-	}
+// SetLanguage selects the language of the code mode by name and reports
+// whether it is known. Prompts already fetched for the previous language
+// are the caller's to discard.
+func (s *Service) SetLanguage(name string) bool {
+	lang, ok := LanguageByName(name)
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lang = lang
+	return true
+}
 
-	if len(codeLines) == 0 {
-		return ""
-	}
-	out := strings.Join(strings.Fields(strings.Join(codeLines, " ")), " ")
-	if len(out) > 260 {
-		out = out[:260]
-		if i := strings.LastIndex(out, " "); i > 80 {
-			out = out[:i]
-		}
-	}
-	return strings.TrimSpace(out)
+// Language returns the language of the code mode.
+func (s *Service) Language() Language {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lang
 }
 
-func (s *Service) fetchCode(endpoint string) (string, error) {
-	if endpoint == "" {
-		return "", fmt.Errorf("go example endpoint is empty")
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
-	defer cancel()
+// SetText loads the chunks of the custom text mode, see SplitText, and
+// moves to the first one; chunks must not be modified afterwards.
+func (s *Service) SetText(chunks []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.text = chunks
+	s.textPos = 0
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Accept", "application/json, text/plain;q=0.9")
+// SeekText makes chunk i the next prompt of the custom text mode. Out of
+// range positions wrap around.
+func (s *Service) SeekText(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.text) == 0 {
+		return
+	}
+	s.textPos = (i%len(s.text) + len(s.text)) % len(s.text)
+}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("go example API status %d", resp.StatusCode)
-	}
+func (s *Service) intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.Intn(n)
+}
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
-	if err != nil {
-		return "", err
+func (s *Service) provider(mode Mode) Provider {
+	if mode < 0 || int(mode) >= len(s.providers) {
+		return s.providers[ModeNormal]
 	}
+	return s.providers[mode]
+}
 
-	var payload codeResponse
-	if err := json.Unmarshal(body, &payload); err == nil {
-		for _, c := range []string{cleanGoTypingPrompt(payload.Content), cleanGoTypingPrompt(payload.Code), cleanGoTypingPrompt(payload.Text)} {
-			if c != "" {
-				return c, nil
-			}
+// Next returns the prompt after previous, trying the remote endpoint first
+// for remote modes. It may block on the network; use Remote, Fetch and
+// Fallback to keep network access off the caller's goroutine.
+func (s *Service) Next(mode Mode, previous string) string {
+	if s.Remote(mode) {
+		if p, err := s.Fetch(mode, previous); err == nil {
+			return p
 		}
 	}
+	return s.Fallback(mode, previous)
+}
 
-	plain := cleanGoTypingPrompt(string(body))
-	if plain == "" {
-		return "", fmt.Errorf("go example payload is empty")
-	}
-	return plain, nil
+// Remote reports whether prompts for mode come from a remote endpoint.
+func (s *Service) Remote(mode Mode) bool {
+	rp, ok := s.provider(mode).(RemoteProvider)
+	return ok && rp.Remote()
 }
 
-func (s *Service) nextCode(previous string) string {
-	pickFallback := func() string {
-		return pickDifferent(s.rng, s.cfg.GoExamples, previous, `fmt.Println("hello, tuiper")`)
-	}
-	if time.Now().Before(s.codeBackoffUntil) {
-		return pickFallback()
-	}
-	if strings.TrimSpace(s.cfg.GoExampleEndpoint) == "" {
-		return pickFallback()
-	}
+// Fetch asks the provider of mode for its next prompt, which for remote
+// providers means a network request.
+func (s *Service) Fetch(mode Mode, previous string) (string, error) {
+	return s.provider(mode).Next(context.Background(), previous)
+}
 
-	var lastErr error
-	for i := 0; i < 3; i++ {
-		ex, err := s.fetchCode(s.cfg.GoExampleEndpoint)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		if ex != previous {
-			return ex
-		}
+// Fallback returns a prompt without touching the network.
+func (s *Service) Fallback(mode Mode, previous string) string {
+	p := s.provider(mode)
+	if rp, ok := p.(RemoteProvider); ok {
+		return rp.Fallback(previous)
 	}
-	if lastErr != nil {
-		s.codeBackoffUntil = time.Now().Add(15 * time.Second)
+	next, err := p.Next(context.Background(), previous)
+	if err != nil || next == "" {
+		return "keep typing with steady rhythm."
 	}
-	return pickFallback()
+	return next
 }
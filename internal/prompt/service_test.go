@@ -11,12 +11,14 @@ import (
 
 func testService() *Service {
 	return New(Config{
-		Words:             []string{"alpha", "beta", "gamma"},
-		SpecialCharWords:  []string{"!@#", "$%^"},
-		PromptWordCount:   4,
-		QuoteEndpoint:     "https://example.test/quote",
-		GoExampleEndpoint: "",
-		GoExamples:        []string{`fmt.Println("a")`, `fmt.Println("b")`},
+		Words:            []string{"alpha", "beta", "gamma"},
+		SpecialCharWords: []string{"!@#", "$%^"},
+		PromptWordCount:  4,
+		QuoteEndpoint:    "https://example.test/quote",
+		CodeExamples: map[string][]string{
+			"go":     {`fmt.Println("a")`, `fmt.Println("b")`},
+			"python": {`print("a")`, `print("b")`},
+		},
 	})
 }
 
@@ -66,15 +68,43 @@ import "fmt"
 func main() {
 	fmt.Println("hello")
 }`
-	got := cleanGoTypingPrompt(raw)
-	want := `func main() { fmt.Println("hello") }`
+	got := languages[0].Clean(raw)
+	want := "func main() {\n    fmt.Println(\"hello\")\n}"
 	if got != want {
 		t.Fatalf("got %q, want %q", got, want)
 	}
 }
 
+func TestCleanGoTypingPromptKeepsLayout(t *testing.T) {
+	raw := "\t\tif err != nil {   \r\n\n\t\t\treturn err\n\t\t}\n"
+	if got, want := languages[0].Clean(raw), "if err != nil {\n    return err\n}"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	long := strings.Repeat("x := 1\n", 40)
+	got := languages[0].Clean(long)
+	if lines := strings.Count(got, "\n") + 1; lines != maxCodeLines {
+		t.Fatalf("cut to %d lines, want %d", lines, maxCodeLines)
+	}
+}
+
 type roundTripFunc func(req *http.Request) (*http.Response, error)
 
 func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
+
+func TestReseedReproducesPrompts(t *testing.T) {
+	s := testService()
+	s.Reseed(42)
+	first := []string{s.Next(ModeNormal, ""), s.Next(ModeSpecialChars, "")}
+	other := testService()
+	other.Reseed(42)
+	second := []string{other.Next(ModeNormal, ""), other.Next(ModeSpecialChars, "")}
+	if first[0] != second[0] || first[1] != second[1] {
+		t.Fatalf("same seed produced %q and %q", first, second)
+	}
+	if other.Seed() != 42 {
+		t.Fatalf("Seed() = %d, want 42", other.Seed())
+	}
+}
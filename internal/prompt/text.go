@@ -0,0 +1,156 @@
+package prompt
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// textProvider serves the chunks of a custom text in order, wrapping
+// around at the end. The chunks and the cursor live in the Service so the
+// UI can load a text and resume it at any chunk.
+type textProvider struct {
+	next func() (string, bool)
+}
+
+func (p *textProvider) Name() string  { return "text" }
+func (p *textProvider) Label() string { return "Custom Text" }
+
+func (p *textProvider) Bind(env Env) Provider {
+	return &textProvider{next: env.nextChunk}
+}
+
+func (p *textProvider) Next(context.Context, string) (string, error) {
+	if p.next != nil {
+		if c, ok := p.next(); ok {
+			return c, nil
+		}
+	}
+	return "load your own text with tuiper -file notes.txt or cat notes.txt | tuiper -", nil
+}
+
+// proseLineLen is the shortest line, in runes, of a hard-wrapped prose
+// paragraph. Paragraphs with shorter or indented lines are taken to be code
+// or lists and are chunked line by line.
+const proseLineLen = 40
+
+// typographic maps punctuation that is hard to type to its ASCII form.
+var typographic = strings.NewReplacer(
+	"‘", "'", "’", "'", "“", `"`, "”", `"`,
+	"–", "-", "—", "-", "…", "...", " ", " ",
+)
+
+// SplitText cuts text into prompts of at most words words. Prose is split
+// between sentences and code or lists between lines; a sentence or line
+// longer than a prompt is split between words. Runs of whitespace become
+// single spaces, typographic punctuation becomes ASCII and other
+// characters that cannot be typed are dropped.
+func SplitText(text string, words int) []string {
+	if words < 1 {
+		words = 1
+	}
+	var units []string
+	for _, para := range paragraphs(text) {
+		if isProse(para) {
+			units = append(units, sentences(strings.Join(para, " "))...)
+			continue
+		}
+		for _, l := range para {
+			units = append(units, strings.Join(strings.Fields(l), " "))
+		}
+	}
+
+	var chunks, cur []string
+	flush := func() {
+		if len(cur) > 0 {
+			chunks = append(chunks, strings.Join(cur, " "))
+			cur = nil
+		}
+	}
+	for _, u := range units {
+		fields := strings.Fields(u)
+		if len(cur)+len(fields) > words {
+			flush()
+		}
+		for len(fields) > words {
+			chunks = append(chunks, strings.Join(fields[:words], " "))
+			fields = fields[words:]
+		}
+		cur = append(cur, fields...)
+	}
+	flush()
+	return chunks
+}
+
+// paragraphs splits text at blank lines into its non-empty lines, after
+// cleaning every line of untypeable characters.
+func paragraphs(text string) [][]string {
+	text = typographic.Replace(strings.ReplaceAll(text, "\r\n", "\n"))
+	var out [][]string
+	var para []string
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.TrimRightFunc(typeable(l), unicode.IsSpace)
+		if strings.TrimSpace(l) == "" {
+			if len(para) > 0 {
+				out = append(out, para)
+				para = nil
+			}
+			continue
+		}
+		para = append(para, l)
+	}
+	if len(para) > 0 {
+		out = append(out, para)
+	}
+	return out
+}
+
+// typeable drops the runes of s that a keyboard does not produce, keeping
+// tabs as whitespace.
+func typeable(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r == utf8.RuneError || !unicode.IsPrint(r):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func isProse(para []string) bool {
+	for i, l := range para {
+		if l[0] == ' ' {
+			return false
+		}
+		if i < len(para)-1 && utf8.RuneCountInString(l) < proseLineLen {
+			return false
+		}
+	}
+	return true
+}
+
+// sentences splits s after every '.', '!' or '?' that ends a word.
+func sentences(s string) []string {
+	var out, cur []string
+	for _, f := range strings.Fields(s) {
+		cur = append(cur, f)
+		if endsSentence(f) {
+			out = append(out, strings.Join(cur, " "))
+			cur = nil
+		}
+	}
+	if len(cur) > 0 {
+		out = append(out, strings.Join(cur, " "))
+	}
+	return out
+}
+
+// endsSentence reports whether word ends with sentence punctuation, possibly
+// followed by closing quotes or brackets.
+func endsSentence(word string) bool {
+	word = strings.TrimRight(word, `"')]`)
+	return word != "" && strings.ContainsRune(".!?", rune(word[len(word)-1]))
+}
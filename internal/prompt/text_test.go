@@ -0,0 +1,81 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		words int
+		want  []string
+	}{
+		{
+			name:  "prose packs whole sentences",
+			text:  "The fox ran. It was quick! Was the dog lazy?\r\nNobody knows.",
+			words: 6,
+			want:  []string{"The fox ran. It was quick!", "Was the dog lazy? Nobody knows."},
+		},
+		{
+			name:  "wrapped prose joins lines",
+			text:  "Typing practice works best on text you care about,\nsuch as your notes. Short drills help.\n",
+			words: 13,
+			want: []string{
+				"Typing practice works best on text you care about, such as your notes.",
+				"Short drills help.",
+			},
+		},
+		{
+			name:  "code keeps lines together",
+			text:  "func main() {\n\tfmt.Println(\"hi\")\n}\n\nvar x = 1\n",
+			words: 5,
+			want:  []string{`func main() { fmt.Println("hi") }`, "var x = 1"},
+		},
+		{
+			name:  "long sentences split between words",
+			text:  "one two three four five six seven.",
+			words: 3,
+			want:  []string{"one two three", "four five six", "seven."},
+		},
+		{
+			name:  "typographic punctuation becomes ascii",
+			text:  "“Don’t” — wait…\x00",
+			words: 10,
+			want:  []string{`"Don't" - wait...`},
+		},
+		{
+			name:  "blank text",
+			text:  " \n\t\n",
+			words: 5,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := SplitText(c.text, c.words); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("SplitText = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestTextModeServesChunksInOrder(t *testing.T) {
+	s := testService()
+	if got := s.Next(ModeText, ""); got == "" {
+		t.Fatal("expected a hint prompt without text")
+	}
+	s.SetText([]string{"one.", "two.", "three."})
+	s.SeekText(1)
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, s.Next(ModeText, ""))
+	}
+	if want := []string{"two.", "three.", "one.", "two."}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("prompts = %q, want %q", got, want)
+	}
+	s.SeekText(-1)
+	if got := s.Next(ModeText, ""); got != "three." {
+		t.Fatalf("after SeekText(-1) got %q, want %q", got, "three.")
+	}
+}
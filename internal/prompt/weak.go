@@ -0,0 +1,41 @@
+package prompt
+
+// Weakness scores the characters and bigrams a typist mistypes or types
+// slowly; larger is weaker and missing entries are not weak. The weak keys
+// mode samples words in proportion to 1 + Score(word).
+type Weakness struct {
+	Chars   map[rune]float64
+	Bigrams map[[2]rune]float64
+}
+
+// Score sums the weakness of every character and adjacent pair in word.
+func (w Weakness) Score(word string) float64 {
+	var score float64
+	runes := []rune(word)
+	for i, r := range runes {
+		score += w.Chars[r]
+		if i > 0 {
+			score += w.Bigrams[[2]rune{runes[i-1], r}]
+		}
+	}
+	return score
+}
+
+// weightScale is the resolution of weighted sampling on top of Intn.
+const weightScale = 1 << 20
+
+// pickWeighted draws an index with probability proportional to weights.
+func pickWeighted(intn func(int) int, weights []float64) int {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	target := float64(intn(weightScale)) / weightScale * total
+	for i, w := range weights {
+		if target < w {
+			return i
+		}
+		target -= w
+	}
+	return len(weights) - 1
+}
@@ -0,0 +1,443 @@
+// Package theme holds the colour palettes of the UI and turns them into
+// lipgloss styles.
+//
+// A Theme only names colours by role. Styles derives every style the UI
+// uses from them, and falls back to text attributes (reverse video,
+// underline, faint) for roles without a colour, so the monochrome theme
+// stays usable on terminals without colour or with NO_COLOR set.
+package theme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"tuitype/internal/highlight"
+)
+
+// Color is a colour for light and dark terminal backgrounds: a hex value
+// such as "#cdd6f4" or an ANSI colour number such as "12". The empty
+// Color is the terminal's default.
+//
+// In JSON a Color is either one string for both backgrounds or an object
+// {"light": "...", "dark": "..."}.
+type Color struct {
+	Light string `json:"light"`
+	Dark  string `json:"dark"`
+}
+
+// Same is the Color s on both backgrounds.
+func Same(s string) Color { return Color{Light: s, Dark: s} }
+
+func (c Color) empty() bool { return c.Light == "" && c.Dark == "" }
+
+func (c Color) terminal() lipgloss.TerminalColor {
+	if c.empty() {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.AdaptiveColor{Light: c.Light, Dark: c.Dark}
+}
+
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*c = Same(s)
+		return nil
+	}
+	type pair Color
+	var p pair
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("colour must be a string or {\"light\", \"dark\"}")
+	}
+	*c = Color(p)
+	return nil
+}
+
+func (c Color) MarshalJSON() ([]byte, error) {
+	if c.Light == c.Dark {
+		return json.Marshal(c.Light)
+	}
+	type pair Color
+	return json.Marshal(pair(c))
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func (c Color) check() error {
+	for _, s := range []string{c.Light, c.Dark} {
+		if s == "" || hexColor.MatchString(s) {
+			continue
+		}
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+			continue
+		}
+		return fmt.Errorf("invalid colour %q (want #rgb, #rrggbb or 0-255)", s)
+	}
+	return nil
+}
+
+// Theme is a palette of colours by role.
+type Theme struct {
+	// Name is the built-in or config name the theme was resolved from.
+	Name string `json:"-"`
+
+	Text    Color `json:"text"`
+	Muted   Color `json:"muted"`
+	Pending Color `json:"pending"`
+	Accent  Color `json:"accent"`
+	Error   Color `json:"error"`
+	// Surface is the background of highlighted items, such as the
+	// foreground of the cursor.
+	Surface Color `json:"surface"`
+	// Heat colours the heatmap keys from low to high.
+	Heat []Color `json:"heat"`
+
+	Keyword  Color `json:"keyword"`
+	Builtin  Color `json:"builtin"`
+	String   Color `json:"string"`
+	Number   Color `json:"number"`
+	Comment  Color `json:"comment"`
+	Operator Color `json:"operator"`
+
+	// BrightPending keeps highlighted code at full brightness before it
+	// is typed instead of dimming it.
+	BrightPending bool `json:"bright_pending"`
+}
+
+// Custom is a user-defined theme from the config file: Base, a built-in
+// theme, with the colours set in Theme replacing its own.
+type Custom struct {
+	Base string `json:"base"`
+	Theme
+}
+
+// Default is the name of the theme used when none is configured.
+const Default = "catppuccin"
+
+// Monochrome is the name of the theme without colours, forced by NO_COLOR.
+const Monochrome = "monochrome"
+
+var builtins = []Theme{
+	{
+		// Catppuccin Latte on light backgrounds, Mocha on dark ones.
+		Name:    "catppuccin",
+		Text:    Color{Light: "#4c4f69", Dark: "#cdd6f4"},
+		Muted:   Color{Light: "#6c6f85", Dark: "#a6adc8"},
+		Pending: Color{Light: "#6c6f85", Dark: "#a6adc8"},
+		Accent:  Color{Light: "#df8e1d", Dark: "#f9e2af"},
+		Error:   Color{Light: "#d20f39", Dark: "#f38ba8"},
+		Surface: Color{Light: "#ccd0da", Dark: "#313244"},
+		Heat: []Color{
+			{Light: "#40a02b", Dark: "#a6e3a1"},
+			{Light: "#df8e1d", Dark: "#f9e2af"},
+			{Light: "#fe640b", Dark: "#fab387"},
+			{Light: "#d20f39", Dark: "#f38ba8"},
+		},
+		Keyword:  Color{Light: "#8839ef", Dark: "#cba6f7"},
+		Builtin:  Color{Light: "#1e66f5", Dark: "#89b4fa"},
+		String:   Color{Light: "#40a02b", Dark: "#a6e3a1"},
+		Number:   Color{Light: "#fe640b", Dark: "#fab387"},
+		Comment:  Color{Light: "#7c7f93", Dark: "#9399b2"},
+		Operator: Color{Light: "#04a5e5", Dark: "#89dceb"},
+	},
+	{
+		Name:    "high-contrast",
+		Text:    Color{Light: "#000000", Dark: "#ffffff"},
+		Muted:   Color{Light: "#303030", Dark: "#d0d0d0"},
+		Pending: Color{Light: "#303030", Dark: "#d0d0d0"},
+		Accent:  Color{Light: "#0000c0", Dark: "#ffff00"},
+		Error:   Color{Light: "#c00000", Dark: "#ff5f5f"},
+		Surface: Color{Light: "#ffffff", Dark: "#000000"},
+		Heat: []Color{
+			{Light: "#008000", Dark: "#00ff00"},
+			{Light: "#806000", Dark: "#ffff00"},
+			{Light: "#c05000", Dark: "#ff8700"},
+			{Light: "#c00000", Dark: "#ff0000"},
+		},
+		Keyword:       Color{Light: "#8000a0", Dark: "#ff87ff"},
+		Builtin:       Color{Light: "#0000c0", Dark: "#87d7ff"},
+		String:        Color{Light: "#006000", Dark: "#87ff87"},
+		Number:        Color{Light: "#a04000", Dark: "#ffaf5f"},
+		Comment:       Color{Light: "#505050", Dark: "#bcbcbc"},
+		Operator:      Color{Light: "#006080", Dark: "#5fffff"},
+		BrightPending: true,
+	},
+	{
+		Name: Monochrome,
+	},
+	{
+		Name:    "solarized",
+		Text:    Color{Light: "#073642", Dark: "#eee8d5"},
+		Muted:   Color{Light: "#657b83", Dark: "#839496"},
+		Pending: Color{Light: "#657b83", Dark: "#93a1a1"},
+		Accent:  Same("#b58900"),
+		Error:   Same("#dc322f"),
+		Surface: Color{Light: "#eee8d5", Dark: "#073642"},
+		Heat: []Color{
+			Same("#859900"),
+			Same("#b58900"),
+			Same("#cb4b16"),
+			Same("#dc322f"),
+		},
+		Keyword:  Same("#859900"),
+		Builtin:  Same("#268bd2"),
+		String:   Same("#2aa198"),
+		Number:   Same("#d33682"),
+		Comment:  Color{Light: "#93a1a1", Dark: "#586e75"},
+		Operator: Same("#6c71c4"),
+	},
+	{
+		Name:    "gruvbox",
+		Text:    Color{Light: "#3c3836", Dark: "#ebdbb2"},
+		Muted:   Color{Light: "#7c6f64", Dark: "#a89984"},
+		Pending: Color{Light: "#7c6f64", Dark: "#bdae93"},
+		Accent:  Color{Light: "#b57614", Dark: "#fabd2f"},
+		Error:   Color{Light: "#9d0006", Dark: "#fb4934"},
+		Surface: Color{Light: "#ebdbb2", Dark: "#3c3836"},
+		Heat: []Color{
+			{Light: "#79740e", Dark: "#b8bb26"},
+			{Light: "#b57614", Dark: "#fabd2f"},
+			{Light: "#af3a03", Dark: "#fe8019"},
+			{Light: "#9d0006", Dark: "#fb4934"},
+		},
+		Keyword:  Color{Light: "#9d0006", Dark: "#fb4934"},
+		Builtin:  Color{Light: "#b57614", Dark: "#fabd2f"},
+		String:   Color{Light: "#79740e", Dark: "#b8bb26"},
+		Number:   Color{Light: "#8f3f71", Dark: "#d3869b"},
+		Comment:  Color{Light: "#928374", Dark: "#928374"},
+		Operator: Color{Light: "#427b58", Dark: "#8ec07c"},
+	},
+}
+
+// Names returns the names of the built-in themes; the first is Default.
+func Names() []string {
+	names := make([]string, len(builtins))
+	for i, t := range builtins {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Builtin looks a built-in theme up by name.
+func Builtin(name string) (Theme, bool) {
+	for _, t := range builtins {
+		if t.Name == name {
+			t.Heat = append([]Color(nil), t.Heat...)
+			return t, true
+		}
+	}
+	return Theme{}, false
+}
+
+// Resolve returns the theme called name: a built-in theme or one of
+// custom. It reports unknown names, custom themes based on an unknown
+// theme and malformed colours.
+func Resolve(name string, custom map[string]Custom) (Theme, error) {
+	if name == "" {
+		name = Default
+	}
+	if t, ok := Builtin(name); ok {
+		return t, nil
+	}
+	c, ok := custom[name]
+	if !ok {
+		known := Names()
+		for n := range custom {
+			known = append(known, n)
+		}
+		sort.Strings(known[len(builtins):])
+		return Theme{}, fmt.Errorf("unknown theme %q (want one of %s)", name, strings.Join(known, ", "))
+	}
+	base := c.Base
+	if base == "" {
+		base = Default
+	}
+	t, ok := Builtin(base)
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %q: unknown base theme %q (want one of %s)", name, base, strings.Join(Names(), ", "))
+	}
+	t.Name = name
+	if err := t.override(c.Theme); err != nil {
+		return Theme{}, err
+	}
+	return t, nil
+}
+
+// CheckCustom validates every custom theme and rejects names of built-in
+// themes.
+func CheckCustom(custom map[string]Custom) error {
+	names := make([]string, 0, len(custom))
+	for n := range custom {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, n := range names {
+		if _, ok := Builtin(n); ok {
+			errs = append(errs, fmt.Errorf("theme %q is built in; give the custom theme another name", n))
+			continue
+		}
+		if _, err := Resolve(n, custom); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// override replaces the colours of t set in o. Every malformed colour is
+// reported, prefixed with the name of t.
+func (t *Theme) override(o Theme) error {
+	colors := []struct {
+		name string
+		dst  *Color
+		src  Color
+	}{
+		{"text", &t.Text, o.Text},
+		{"muted", &t.Muted, o.Muted},
+		{"pending", &t.Pending, o.Pending},
+		{"accent", &t.Accent, o.Accent},
+		{"error", &t.Error, o.Error},
+		{"surface", &t.Surface, o.Surface},
+		{"keyword", &t.Keyword, o.Keyword},
+		{"builtin", &t.Builtin, o.Builtin},
+		{"string", &t.String, o.String},
+		{"number", &t.Number, o.Number},
+		{"comment", &t.Comment, o.Comment},
+		{"operator", &t.Operator, o.Operator},
+	}
+	var errs []error
+	for _, c := range colors {
+		if err := c.src.check(); err != nil {
+			errs = append(errs, fmt.Errorf("theme %q: %s: %w", t.Name, c.name, err))
+			continue
+		}
+		if !c.src.empty() {
+			*c.dst = c.src
+		}
+	}
+	for _, c := range o.Heat {
+		if c.empty() {
+			errs = append(errs, fmt.Errorf("theme %q: heat: colours must not be empty", t.Name))
+		} else if err := c.check(); err != nil {
+			errs = append(errs, fmt.Errorf("theme %q: heat: %w", t.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if len(o.Heat) > 0 {
+		t.Heat = append([]Color(nil), o.Heat...)
+	}
+	t.BrightPending = t.BrightPending || o.BrightPending
+	return nil
+}
+
+// Styles are the lipgloss styles of a theme.
+type Styles struct {
+	Title    lipgloss.Style
+	Accent   lipgloss.Style
+	Subtle   lipgloss.Style
+	Text     lipgloss.Style
+	Error    lipgloss.Style
+	Selected lipgloss.Style
+	Card     lipgloss.Style
+
+	// Typing view: Correct and Pending are plain text before and after
+	// the cursor, Ghost the ghost caret.
+	Correct lipgloss.Style
+	Wrong   lipgloss.Style
+	Pending lipgloss.Style
+	Cursor  lipgloss.Style
+	Ghost   lipgloss.Style
+
+	// Heat are the heatmap levels from low to high; HeatEmpty marks keys
+	// without data.
+	Heat      []lipgloss.Style
+	HeatEmpty lipgloss.Style
+
+	syntax        map[highlight.Class]lipgloss.Style
+	brightPending bool
+}
+
+// Styles derives the UI styles from the theme.
+func (t Theme) Styles() Styles {
+	text, muted, accent := t.Text.terminal(), t.Muted.terminal(), t.Accent.terminal()
+	surface, errColor := t.Surface.terminal(), t.Error.terminal()
+	s := Styles{
+		Title:     lipgloss.NewStyle().Bold(true).Foreground(accent),
+		Accent:    lipgloss.NewStyle().Foreground(accent),
+		Subtle:    lipgloss.NewStyle().Foreground(muted),
+		Text:      lipgloss.NewStyle().Foreground(text),
+		Error:     lipgloss.NewStyle().Foreground(errColor),
+		Selected:  lipgloss.NewStyle().Foreground(surface).Background(accent).Bold(true).Padding(0, 1),
+		Card:      lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(muted).Padding(1, 3),
+		Correct:   lipgloss.NewStyle().Foreground(text),
+		Wrong:     lipgloss.NewStyle().Foreground(errColor).Underline(true),
+		Pending:   lipgloss.NewStyle().Foreground(t.Pending.terminal()),
+		Cursor:    lipgloss.NewStyle().Foreground(surface).Background(accent).Bold(true),
+		Ghost:     lipgloss.NewStyle().Foreground(surface).Background(muted),
+		HeatEmpty: lipgloss.NewStyle().Foreground(muted).Background(surface),
+		syntax:    map[highlight.Class]lipgloss.Style{},
+
+		brightPending: t.BrightPending,
+	}
+	if t.Accent.empty() {
+		s.Selected = lipgloss.NewStyle().Reverse(true).Bold(true).Padding(0, 1)
+		s.Cursor = lipgloss.NewStyle().Reverse(true).Bold(true)
+	}
+	if t.Muted.empty() {
+		s.Ghost = lipgloss.NewStyle().Underline(true)
+	}
+	if t.Pending.empty() {
+		s.Pending = lipgloss.NewStyle().Faint(true)
+	}
+	if t.Error.empty() {
+		s.Wrong = s.Wrong.Bold(true)
+	}
+	for _, c := range t.Heat {
+		s.Heat = append(s.Heat, lipgloss.NewStyle().Foreground(surface).Background(c.terminal()))
+	}
+	if len(s.Heat) == 0 {
+		s.Heat = []lipgloss.Style{
+			lipgloss.NewStyle(),
+			lipgloss.NewStyle().Underline(true),
+			lipgloss.NewStyle().Bold(true).Underline(true),
+			lipgloss.NewStyle().Reverse(true),
+		}
+		s.HeatEmpty = lipgloss.NewStyle().Faint(true)
+	}
+	for class, c := range map[highlight.Class]Color{
+		highlight.Keyword:  t.Keyword,
+		highlight.Builtin:  t.Builtin,
+		highlight.String:   t.String,
+		highlight.Number:   t.Number,
+		highlight.Comment:  t.Comment,
+		highlight.Operator: t.Operator,
+	} {
+		if !c.empty() {
+			s.syntax[class] = lipgloss.NewStyle().Foreground(c.terminal())
+		}
+	}
+	return s
+}
+
+// Code is the style of a code character of the given token class, typed
+// or still pending. Classes without a colour use Correct and Pending.
+func (s Styles) Code(class highlight.Class, typed bool) lipgloss.Style {
+	st, ok := s.syntax[class]
+	switch {
+	case !ok && typed:
+		return s.Correct
+	case !ok:
+		return s.Pending
+	case !typed && !s.brightPending:
+		return st.Faint(true)
+	}
+	return st
+}
@@ -0,0 +1,99 @@
+package theme
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"tuitype/internal/highlight"
+)
+
+func TestResolveCustomTheme(t *testing.T) {
+	var custom map[string]Custom
+	data := `{
+		"mine": {"base": "gruvbox", "pending": "#ffffff", "error": {"light": "1", "dark": "9"}},
+		"plain": {"heat": ["#111", "#222"]}
+	}`
+	if err := json.Unmarshal([]byte(data), &custom); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if err := CheckCustom(custom); err != nil {
+		t.Fatalf("CheckCustom returned error: %v", err)
+	}
+
+	mine, err := Resolve("mine", custom)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	gruvbox, _ := Builtin("gruvbox")
+	if mine.Name != "mine" || mine.Pending != Same("#ffffff") || mine.Error != (Color{Light: "1", Dark: "9"}) {
+		t.Fatalf("mine = %+v, want the overridden colours", mine)
+	}
+	if mine.Text != gruvbox.Text || len(mine.Heat) != len(gruvbox.Heat) {
+		t.Fatalf("mine = %+v, want the other colours of gruvbox", mine)
+	}
+
+	plain, err := Resolve("plain", custom)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if catppuccin, _ := Builtin(Default); plain.Text != catppuccin.Text || len(plain.Heat) != 2 {
+		t.Fatalf("plain = %+v, want the default theme with two heat levels", plain)
+	}
+
+	if th, err := Resolve("", nil); err != nil || th.Name != Default {
+		t.Fatalf("Resolve(\"\") = %q, %v; want the default theme", th.Name, err)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		custom map[string]Custom
+		want   string
+	}{
+		{"nope", nil, `unknown theme "nope"`},
+		{"x", map[string]Custom{"x": {Base: "nope"}}, `unknown base theme "nope"`},
+		{"x", map[string]Custom{"x": {Theme: Theme{Accent: Same("orange")}}}, `accent: invalid colour "orange"`},
+		{"x", map[string]Custom{"x": {Theme: Theme{Heat: []Color{{}}}}}, "heat: colours must not be empty"},
+	}
+	for _, c := range cases {
+		if _, err := Resolve(c.name, c.custom); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Fatalf("Resolve(%q) error = %v, want %q", c.name, err, c.want)
+		}
+	}
+	if err := CheckCustom(map[string]Custom{"gruvbox": {}}); err == nil {
+		t.Fatal("expected an error for a custom theme named like a built-in one")
+	}
+}
+
+func TestMonochromeStylesUseAttributes(t *testing.T) {
+	th, ok := Builtin(Monochrome)
+	if !ok {
+		t.Fatal("no monochrome theme")
+	}
+	s := th.Styles()
+	if !s.Cursor.GetReverse() || !s.Selected.GetReverse() {
+		t.Fatal("cursor and selection must be reverse video without colours")
+	}
+	if !s.Pending.GetFaint() || !s.Wrong.GetUnderline() {
+		t.Fatal("pending text must be faint and wrong text underlined")
+	}
+	if got := s.Code(highlight.Keyword, false); !got.GetFaint() {
+		t.Fatal("pending code must fall back to the pending style")
+	}
+	if len(s.Heat) < 2 {
+		t.Fatalf("%d heat levels, want distinct levels without colours", len(s.Heat))
+	}
+}
+
+func TestHighContrastKeepsPendingCodeBright(t *testing.T) {
+	th, _ := Builtin("high-contrast")
+	if th.Styles().Code(highlight.String, false).GetFaint() {
+		t.Fatal("high-contrast pending code must not be faint")
+	}
+	th, _ = Builtin(Default)
+	if !th.Styles().Code(highlight.String, false).GetFaint() {
+		t.Fatal("default pending code should be faint")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tuitype/internal/config"
+	"tuitype/internal/engine"
+	"tuitype/internal/history"
+)
+
+// testLength is how long a test runs: for a fixed duration, until a number
+// of words is typed, or until a single prompt is finished. kind is one of
+// the history.Kind constants.
+type testLength struct {
+	kind     string
+	duration time.Duration
+	words    int
+}
+
+func timeLength(d time.Duration) testLength {
+	return testLength{kind: history.KindTime, duration: d}
+}
+
+func (l testLength) String() string {
+	switch l.kind {
+	case history.KindWords:
+		return fmt.Sprintf("%d words", l.words)
+	case history.KindPrompt:
+		return "1 prompt"
+	}
+	return l.duration.String()
+}
+
+// code is the compact form used in test codes: "30s", "50w" or "1p".
+func (l testLength) code() string {
+	switch l.kind {
+	case history.KindWords:
+		return strconv.Itoa(l.words) + "w"
+	case history.KindPrompt:
+		return "1p"
+	}
+	return l.duration.String()
+}
+
+func parseLength(s string) (testLength, error) {
+	switch {
+	case s == "1p":
+		return testLength{kind: history.KindPrompt}, nil
+	case strings.HasSuffix(s, "w"):
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "w"))
+		if err != nil || n <= 0 {
+			return testLength{}, fmt.Errorf("invalid word count %q", s)
+		}
+		return testLength{kind: history.KindWords, words: n}, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return testLength{}, fmt.Errorf("invalid duration %q", s)
+	}
+	return timeLength(d), nil
+}
+
+// lengthOf returns the length a recorded session was started with.
+func lengthOf(rec history.Session) testLength {
+	switch kind := rec.LengthKind(); kind {
+	case history.KindWords:
+		return testLength{kind: kind, words: rec.Words}
+	case history.KindTime:
+		return timeLength(rec.Duration)
+	default:
+		return testLength{kind: kind}
+	}
+}
+
+// options configures the engine to end the session at the length limit.
+// Timed tests are ended by the UI clock instead.
+func (l testLength) options(opts engine.Options) engine.Options {
+	switch l.kind {
+	case history.KindWords:
+		opts.Words = l.words
+	case history.KindPrompt:
+		opts.Prompts = 1
+	}
+	return opts
+}
+
+// lengthOptions lists the lengths offered in the menu: the configured
+// durations, then the word counts, then a single prompt.
+func lengthOptions(cfg config.RuntimeConfig) ([]testLength, []string) {
+	var lengths []testLength
+	var labels []string
+	for i, d := range cfg.DurationOptions {
+		lengths = append(lengths, timeLength(d))
+		labels = append(labels, cfg.DurationLabels[i])
+	}
+	for _, n := range cfg.WordCounts {
+		l := testLength{kind: history.KindWords, words: n}
+		lengths = append(lengths, l)
+		labels = append(labels, l.String())
+	}
+	single := testLength{kind: history.KindPrompt}
+	return append(lengths, single), append(labels, single.String())
+}
@@ -14,6 +14,11 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"tuitype/internal/config"
+	"tuitype/internal/engine"
+	"tuitype/internal/highlight"
+	"tuitype/internal/history"
+	"tuitype/internal/keymap"
+	"tuitype/internal/metrics"
 	"tuitype/internal/prompt"
 )
 
@@ -32,34 +37,57 @@ var splashArt = strings.Join([]string{
 
 type model struct {
 	cfg        config.RuntimeConfig
+	configPath string
 	prompts    *prompt.Service
+	queue      *prompt.Queue
 	modeLabels []string
+	languages  []prompt.Language
+	history    *history.Store
+	replay     *replayer
+	useGhost   bool
 
-	width           int
-	height          int
-	prompt          string
-	inputRunes      []rune
-	totalTyped      int
-	totalCorrect    int
-	sessionDuration time.Duration
-	startedAt       time.Time
-	finishedAt      time.Time
-	selectedMode    prompt.Mode
-	selectedOption  int
-	showSplash      bool
-	selectingMode   bool
-	selectingTime   bool
-	started         bool
-	done            bool
+	width          int
+	height         int
+	session        *engine.Session
+	ghost          *ghost
+	seed           int64
+	lengths        []testLength
+	lengthLabels   []string
+	length         testLength
+	finishedAt     time.Time
+	selectedMode   prompt.Mode
+	selectedOption int
+	selectedLang   int
+	screens        []screen
+	heatMetric     heatMetric
+	keyReport      *metrics.KeyReport
+	heatSessions   int
+	heatErr        error
+	settings       *settingsForm
+	weak           *metrics.KeyReport
+	text           *customText
+	textProgress   string
+	textErr        error
+	done           bool
+	savedID        string
+	saveErr        error
 }
 
+// returnMarker stands for a newline in the prompt, typed with Enter.
+const returnMarker = "⏎"
+
 type tickMsg time.Time
 
+type savedMsg struct {
+	id  string
+	err error
+}
+
 func tickCmd() tea.Cmd {
 	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg { return tickMsg(t) })
 }
 
-func initialModel(cfg config.RuntimeConfig) model {
+func initialModel(cfg config.RuntimeConfig, store *history.Store) model {
 	selected := 0
 	defaultDuration := 30 * time.Second
 	for i, d := range cfg.DurationOptions {
@@ -68,36 +96,274 @@ func initialModel(cfg config.RuntimeConfig) model {
 			break
 		}
 	}
-	if selected >= len(cfg.DurationOptions) {
-		selected = 0
-	}
+	lengths, labels := lengthOptions(cfg)
 	return model{
-		cfg: cfg,
-		prompts: prompt.New(prompt.Config{
-			Words:             cfg.Words,
-			SpecialCharWords:  cfg.SpecialCharWords,
-			PromptWordCount:   cfg.PromptWordCount,
-			QuoteEndpoint:     cfg.QuoteEndpoint,
-			GoExampleEndpoint: cfg.GoExampleEndpoint,
-			GoExamples:        cfg.GoExamples,
-		}),
-		modeLabels:      prompt.ModeLabels(),
-		sessionDuration: cfg.DurationOptions[selected],
-		selectedOption:  selected,
-		selectedMode:    prompt.ModeNormal,
-		showSplash:      true,
+		cfg:            cfg,
+		prompts:        newPromptService(cfg),
+		queue:          prompt.NewQueue(prefetchSize),
+		modeLabels:     prompt.ModeLabels(),
+		languages:      prompt.Languages(),
+		history:        store,
+		lengths:        lengths,
+		lengthLabels:   labels,
+		length:         lengths[selected],
+		selectedOption: selected,
+		selectedMode:   prompt.ModeNormal,
+		screens:        []screen{screenSplash},
 	}
 }
 
+func newPromptService(cfg config.RuntimeConfig) *prompt.Service {
+	return prompt.New(prompt.Config{
+		Words:            cfg.Words,
+		SpecialCharWords: cfg.SpecialCharWords,
+		PromptWordCount:  cfg.PromptWordCount,
+		QuoteEndpoint:    cfg.QuoteEndpoint,
+		CodeEndpoints:    cfg.CodeEndpoints,
+		CodeExamples:     cfg.CodeExamples,
+	})
+}
+
 func (m *model) resetSession() {
-	m.prompt = m.prompts.Next(m.selectedMode, "")
-	m.inputRunes = nil
-	m.totalTyped = 0
-	m.totalCorrect = 0
-	m.startedAt = time.Time{}
+	m.startSession(nil)
+}
+
+// restart abandons the current test, which is not saved, and starts one
+// with the same mode and length. With samePrompts the prompts of the
+// abandoned test are served again, in order, before new ones.
+func (m *model) restart(samePrompts bool) {
+	var retry []string
+	if samePrompts && m.session != nil {
+		retry = m.session.Snapshot().Prompts
+	}
+	m.startSession(retry)
+}
+
+// leaveTest returns to the mode menu. A test still running is abandoned
+// without being saved.
+func (m *model) leaveTest() {
+	m.session = nil
+	m.done = false
+	m.backTo(screenModes)
+}
+
+// keys returns the configured key bindings; models built without a config
+// use the defaults.
+func (m model) keys() keymap.KeyMap {
+	if !m.cfg.Keys.Quit.Enabled() {
+		return keymap.Default()
+	}
+	return m.cfg.Keys
+}
+
+// startSession starts a test of the selected mode and length. The test
+// serves the prompts of retry first, on the seed of the previous test.
+func (m *model) startSession(retry []string) {
+	seed := m.seed
+	if len(retry) == 0 || seed == 0 {
+		seed = m.cfg.Seed
+	}
+	m.startSeeded(seed, retry)
+}
+
+// startSeeded is startSession on the given seed; zero picks a random one.
+func (m *model) startSeeded(seed int64, retry []string) {
+	mode := m.selectedMode
+	m.ghost = nil
+	m.seed = seed
+	if m.seed == 0 {
+		m.seed = prompt.NewSeed()
+	}
+	if m.useGhost {
+		if rec, ok := m.loadGhost(seed); ok {
+			m.ghost = newGhost(rec, engineOptions(mode, m.length))
+			m.seed = rec.Seed
+		}
+	}
+	m.prompts.Reseed(m.seed)
+	if mode == prompt.ModeWeak {
+		m.loadWeakness()
+		m.prompts.SetWeakness(weaknessFrom(m.weak))
+	}
+	if mode == prompt.ModeText && m.text != nil {
+		m.prompts.SeekText(m.text.next)
+	}
+	next := m.nextPromptFunc(mode)
+	if len(retry) > 0 {
+		fresh, served := next, 0
+		next = func(previous string) string {
+			// Draw from the source anyway to keep it in step, e.g. the
+			// position in a custom text.
+			p := fresh(previous)
+			if served < len(retry) {
+				p = retry[served]
+			}
+			served++
+			return p
+		}
+	}
+	opts := engineOptions(mode, m.length)
+	opts.SkipIndent = m.cfg.SkipIndent
+	m.session = engine.New(next(""), next, opts)
 	m.finishedAt = time.Time{}
-	m.started = false
 	m.done = false
+	m.savedID = ""
+	m.saveErr = nil
+	m.textErr = nil
+}
+
+// modeLabel names the selected mode, with the language of Code Practice.
+func (m model) modeLabel() string {
+	label := m.modeLabels[int(m.selectedMode)]
+	if m.selectedMode == prompt.ModeCode {
+		label += " (" + m.prompts.Language().Label + ")"
+	}
+	return label
+}
+
+// textMissing reports whether the Custom Text mode is selected without a
+// text to type; it cannot be started then.
+func (m model) textMissing() bool {
+	return m.selectedMode == prompt.ModeText && m.text == nil
+}
+
+func (m model) testCode() testCode {
+	return testCode{mode: m.selectedMode, language: m.language(), length: m.length, seed: m.seed}
+}
+
+// language is the code language of the selected mode, empty for modes
+// other than Code Practice.
+func (m model) language() string {
+	if m.selectedMode != prompt.ModeCode {
+		return ""
+	}
+	return m.prompts.Language().Name
+}
+
+// startTest skips the menus and begins the test described by code. Its
+// seed applies to this test only.
+func (m *model) startTest(code testCode) {
+	m.selectedMode = code.mode
+	for i, l := range m.languages {
+		if l.Name == code.language {
+			m.selectedLang = i
+			m.prompts.SetLanguage(l.Name)
+			m.queue.Reset(prompt.ModeCode)
+		}
+	}
+	m.length = code.length
+	for i, l := range m.lengths {
+		if l == code.length {
+			m.selectedOption = i
+		}
+	}
+	m.screens = []screen{screenModes, screenTest}
+	m.startSeeded(code.seed, nil)
+}
+
+// openHeatmap switches from the mode menu to the weak keys heatmap,
+// aggregating every saved keystroke log.
+func (m *model) openHeatmap() {
+	m.push(screenHeatmap)
+	m.keyReport, m.heatSessions, m.heatErr = nil, 0, nil
+	if m.history == nil {
+		m.heatErr = fmt.Errorf("session history is disabled")
+		return
+	}
+	m.keyReport, m.heatSessions, m.heatErr = loadKeyReport(m.history.Path(), "")
+}
+
+// loadGhost finds the personal best to race for the selected mode, code
+// language and length, limited to runs of seed if it is set. History
+// errors simply leave the test without a ghost.
+func (m model) loadGhost(seed int64) (history.Session, bool) {
+	if m.history == nil {
+		return history.Session{}, false
+	}
+	sessions, err := m.history.Load()
+	if err != nil {
+		return history.Session{}, false
+	}
+	return bestGhost(sessions, m.selectedMode.Name(), m.language(), m.length, seed)
+}
+
+func engineOptions(mode prompt.Mode, length testLength) engine.Options {
+	return length.options(engine.Options{
+		AutoAdvance: mode == prompt.ModeQuote || mode == prompt.ModeCode,
+	})
+}
+
+// elapsed is the running time of the current session, frozen once the
+// session is done.
+func (m model) elapsed(snap engine.Snapshot) time.Duration {
+	if !snap.Started {
+		return 0
+	}
+	if m.done {
+		return snap.Elapsed(m.finishedAt)
+	}
+	if m.replay != nil {
+		return m.replay.position()
+	}
+	return snap.Elapsed(time.Now())
+}
+
+func (m model) sessionRecord() history.Session {
+	snap := m.session.Snapshot()
+	events := m.session.Events()
+	res := metrics.Compute(events, m.elapsed(snap))
+	prompts := snap.Prompts
+	if len(snap.Input) == 0 {
+		prompts = prompts[:len(prompts)-1]
+	}
+	duration := m.length.duration
+	if m.length.kind != history.KindTime {
+		duration = m.elapsed(snap)
+	}
+	return history.Session{
+		ID:           history.NewID(snap.StartedAt),
+		StartedAt:    snap.StartedAt,
+		FinishedAt:   m.finishedAt,
+		Mode:         m.selectedMode.Name(),
+		Kind:         m.length.kind,
+		Words:        m.length.words,
+		Duration:     duration,
+		TotalTyped:   snap.Typed,
+		TotalCorrect: snap.Correct,
+		WPM:          res.NetWPM,
+		RawWPM:       res.RawWPM,
+		Accuracy:     res.Accuracy,
+		Prompts:      prompts,
+		Keystrokes:   events,
+		Seed:         m.seed,
+		Language:     m.language(),
+		Paused:       snap.PausedFor,
+	}
+}
+
+// finish ends the session at the given time and saves the result.
+func (m *model) finish(at time.Time) tea.Cmd {
+	m.done = true
+	m.finishedAt = at
+	// A replay is never saved: it would duplicate the recorded session.
+	if m.replay != nil {
+		return nil
+	}
+	m.learnWeakness(m.session.Events())
+	if m.selectedMode == prompt.ModeText && m.text != nil {
+		return tea.Batch(m.saveSessionCmd(), m.advanceText(at))
+	}
+	return m.saveSessionCmd()
+}
+
+func (m model) saveSessionCmd() tea.Cmd {
+	if m.history == nil {
+		return nil
+	}
+	store, rec := m.history, m.sessionRecord()
+	return func() tea.Msg {
+		return savedMsg{id: rec.ID, err: store.Append(rec)}
+	}
 }
 
 func pickIndexFromKey(key string, max int) (int, bool) {
@@ -118,6 +384,11 @@ func quickPickHint(max int) string {
 	return fmt.Sprintf("1-%d", max)
 }
 
+// menuHelp is the help entry of a menu of n choices.
+func menuHelp(k keymap.KeyMap, n int) string {
+	return k.MenuPrev.HelpKey() + " " + k.MenuNext.HelpKey() + " or " + quickPickHint(n) + " " + k.Select.HelpKey()
+}
+
 func defaultConfigPath() string {
 	if dir, err := os.UserConfigDir(); err == nil && dir != "" {
 		return filepath.Join(dir, "tuiper", "config.json")
@@ -125,7 +396,11 @@ func defaultConfigPath() string {
 	return "tuiper.json"
 }
 
-func (m model) Init() tea.Cmd { return tickCmd() }
+func defaultHistoryPath() string {
+	return filepath.Join(filepath.Dir(defaultConfigPath()), "history.jsonl")
+}
+
+func (m model) Init() tea.Cmd { return tea.Batch(tickCmd(), m.prefetch()) }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -134,131 +409,220 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		return m, nil
 	case tickMsg:
-		if m.started && !m.done && time.Since(m.startedAt) >= m.sessionDuration {
-			m.done = true
-			m.finishedAt = time.Now()
+		if m.replay != nil && !m.done {
+			m.replay.tick(time.Time(msg), m.session)
+		}
+		if m.session != nil && !m.done {
+			snap := m.session.Snapshot()
+			if m.ghost != nil && snap.Started {
+				m.ghost.advance(m.elapsed(snap))
+			}
+			if snap.Done {
+				return m, tea.Batch(tickCmd(), m.finish(snap.FinishedAt))
+			}
+			if elapsed := m.elapsed(snap); snap.Started && m.length.kind == history.KindTime && elapsed >= m.length.duration {
+				return m, tea.Batch(tickCmd(), m.finish(snap.StartedAt.Add(snap.PausedFor+elapsed)))
+			}
 		}
 		return m, tickCmd()
+	case promptMsg:
+		if msg.err != nil || (msg.mode == prompt.ModeCode && msg.lang != m.prompts.Language().Name) {
+			m.queue.Drop(msg.mode)
+			return m, nil
+		}
+		m.queue.Push(msg.mode, msg.text)
+		return m, nil
+	case savedMsg:
+		m.saveErr = msg.err
+		if msg.err == nil {
+			m.savedID = msg.id
+		}
+		return m, nil
+	case textSavedMsg:
+		m.textErr = msg.err
+		return m, nil
+	case settingsSavedMsg:
+		if m.settings == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			f := *m.settings
+			f.err = msg.err
+			m.settings = &f
+			return m, nil
+		}
+		m.applySettings(msg.cfg)
+		return m, m.prefetch()
+	case tea.BlurMsg:
+		// The terminal lost focus, e.g. to a call: stop the clock until
+		// the pause key is pressed.
+		if m.screen() == screenTest && m.session != nil && m.replay == nil {
+			m.session.Pause(time.Now())
+		}
+		return m, nil
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+c" {
+		keys := m.keys()
+		if keymap.Matches(msg, keys.Quit) {
 			return m, tea.Quit
 		}
+		if m.replay != nil {
+			return m.updateReplayKey(msg)
+		}
 
-		if m.showSplash {
-			if msg.String() == "enter" {
-				m.showSplash = false
-				m.selectingMode = true
+		switch m.screen() {
+		case screenSplash:
+			if keymap.Matches(msg, keys.Select) {
+				m.push(screenModes)
 			}
 			return m, nil
-		}
 
-		if m.selectingMode {
-			switch msg.String() {
-			case "left", "up":
+		case screenHeatmap:
+			switch {
+			case keymap.Matches(msg, keys.HeatmapMetric):
+				m.heatMetric = 1 - m.heatMetric
+			case keymap.Matches(msg, keys.Back, keys.Select, keys.Heatmap):
+				m.back()
+			}
+			return m, nil
+
+		case screenModes:
+			switch {
+			case keymap.Matches(msg, keys.MenuPrev):
 				m.selectedMode--
 				if m.selectedMode < 0 {
 					m.selectedMode = prompt.Mode(len(m.modeLabels) - 1)
 				}
-			case "right", "down":
+			case keymap.Matches(msg, keys.MenuNext):
 				m.selectedMode++
 				if int(m.selectedMode) >= len(m.modeLabels) {
 					m.selectedMode = 0
 				}
-			case "enter":
-				m.selectingMode = false
-				m.selectingTime = true
+			case keymap.Matches(msg, keys.Select):
+				if m.textMissing() {
+					return m, nil
+				}
+				if m.selectedMode == prompt.ModeCode {
+					m.push(screenLanguages)
+					return m, nil
+				}
+				m.push(screenLengths)
+				return m, m.prefetch()
+			case keymap.Matches(msg, keys.Heatmap):
+				m.openHeatmap()
+			case keymap.Matches(msg, keys.Settings):
+				m.openSettings()
+			case keymap.Matches(msg, keys.Back):
+				m.back()
 			default:
 				if idx, ok := pickIndexFromKey(msg.String(), len(m.modeLabels)); ok {
 					m.selectedMode = prompt.Mode(idx)
 				}
 			}
 			return m, nil
-		}
 
-		if m.selectingTime {
-			switch msg.String() {
-			case "left", "up":
+		case screenSettings:
+			return m.updateSettingsKey(msg)
+
+		case screenLanguages:
+			switch {
+			case keymap.Matches(msg, keys.MenuPrev):
+				m.selectedLang--
+				if m.selectedLang < 0 {
+					m.selectedLang = len(m.languages) - 1
+				}
+			case keymap.Matches(msg, keys.MenuNext):
+				m.selectedLang++
+				if m.selectedLang >= len(m.languages) {
+					m.selectedLang = 0
+				}
+			case keymap.Matches(msg, keys.Select):
+				m.push(screenLengths)
+				return m, m.selectLanguage(m.selectedLang)
+			case keymap.Matches(msg, keys.Back):
+				m.back()
+			default:
+				if idx, ok := pickIndexFromKey(msg.String(), len(m.languages)); ok {
+					m.selectedLang = idx
+				}
+			}
+			return m, nil
+
+		case screenLengths:
+			switch {
+			case keymap.Matches(msg, keys.MenuPrev):
 				m.selectedOption--
 				if m.selectedOption < 0 {
-					m.selectedOption = len(m.cfg.DurationOptions) - 1
+					m.selectedOption = len(m.lengths) - 1
 				}
-			case "right", "down":
+			case keymap.Matches(msg, keys.MenuNext):
 				m.selectedOption++
-				if m.selectedOption >= len(m.cfg.DurationOptions) {
+				if m.selectedOption >= len(m.lengths) {
 					m.selectedOption = 0
 				}
-			case "enter":
-				m.sessionDuration = m.cfg.DurationOptions[m.selectedOption]
-				m.selectingTime = false
+			case keymap.Matches(msg, keys.Select):
+				m.length = m.lengths[m.selectedOption]
+				m.push(screenTest)
 				m.resetSession()
+				return m, m.prefetch()
+			case keymap.Matches(msg, keys.Back):
+				m.back()
 			default:
-				if idx, ok := pickIndexFromKey(msg.String(), len(m.cfg.DurationOptions)); ok {
+				if idx, ok := pickIndexFromKey(msg.String(), len(m.lengths)); ok {
 					m.selectedOption = idx
 				}
 			}
 			return m, nil
 		}
 
+		switch {
+		case keymap.Matches(msg, keys.Restart):
+			m.restart(true)
+			return m, m.prefetch()
+		case keymap.Matches(msg, keys.NewTest):
+			m.restart(false)
+			return m, m.prefetch()
+		case keymap.Matches(msg, keys.Back):
+			m.leaveTest()
+			return m, nil
+		case keymap.Matches(msg, keys.Pause):
+			if m.session.Snapshot().Paused {
+				m.session.Resume(time.Now())
+			} else {
+				m.session.Pause(time.Now())
+			}
+			return m, nil
+		}
+		if m.session.Snapshot().Paused {
+			// Keys typed into a hidden prompt do not count.
+			return m, nil
+		}
+
 		if m.done {
-			if msg.String() == "enter" {
-				m.selectingMode = true
+			if keymap.Matches(msg, keys.Select) {
+				m.leaveTest()
 			}
 			return m, nil
 		}
 
 		switch msg.String() {
 		case "backspace":
-			if len(m.inputRunes) > 0 {
-				idx := len(m.inputRunes) - 1
-				r := m.inputRunes[idx]
-				promptRunes := []rune(m.prompt)
-				if idx < len(promptRunes) && promptRunes[idx] == r && m.totalCorrect > 0 {
-					m.totalCorrect--
-				}
-				if m.totalTyped > 0 {
-					m.totalTyped--
-				}
-				m.inputRunes = m.inputRunes[:len(m.inputRunes)-1]
-			}
+			m.session.Backspace(time.Now())
 		default:
-			if len(msg.Runes) > 0 {
-				if !m.started {
-					m.started = true
-					m.startedAt = time.Now()
-				}
-				for _, r := range msg.Runes {
-					promptRunes := []rune(m.prompt)
-					if len(m.inputRunes) >= len(promptRunes) {
-						m.prompt = m.prompts.Next(m.selectedMode, m.prompt)
-						m.inputRunes = m.inputRunes[:0]
-						promptRunes = []rune(m.prompt)
-					}
-					idx := len(m.inputRunes)
-					m.totalTyped++
-					if idx < len(promptRunes) && r == promptRunes[idx] {
-						m.inputRunes = append(m.inputRunes, r)
-						m.totalCorrect++
-						continue
-					}
-					if idx > 0 {
-						prevIdx := idx - 1
-						if prevIdx < len(promptRunes) &&
-							m.inputRunes[prevIdx] != promptRunes[prevIdx] &&
-							r == promptRunes[prevIdx] {
-							// If the user immediately corrects the previously mistyped
-							// character, repair that slot instead of shifting everything.
-							m.inputRunes[prevIdx] = r
-							m.totalCorrect++
-							continue
-						}
-					}
-					m.inputRunes = append(m.inputRunes, r)
-				}
-				if (m.selectedMode == prompt.ModeQuote || m.selectedMode == prompt.ModeCode) && len(m.inputRunes) >= len([]rune(m.prompt)) {
-					m.prompt = m.prompts.Next(m.selectedMode, m.prompt)
-					m.inputRunes = m.inputRunes[:0]
-				}
+			now := time.Now()
+			runes := msg.Runes
+			// Enter types the line breaks of multi-line prompts and is
+			// ignored elsewhere. Tab types nothing: the code provider
+			// expands the tabs of every snippet to spaces.
+			if msg.Type == tea.KeyEnter && strings.ContainsRune(m.session.Snapshot().Prompt, '\n') {
+				runes = []rune{'\n'}
+			}
+			for _, r := range runes {
+				m.session.Type(r, now)
+			}
+			if snap := m.session.Snapshot(); snap.Done {
+				return m, tea.Batch(m.finish(snap.FinishedAt), m.prefetch())
 			}
+			return m, m.prefetch()
 		}
 	}
 	return m, nil
@@ -272,21 +636,15 @@ func (m model) View() string {
 		return "Terminal too small. Resize to at least 24x10."
 	}
 
-	base := lipgloss.AdaptiveColor{Light: "#4c4f69", Dark: "#cdd6f4"}
-	muted := lipgloss.AdaptiveColor{Light: "#6c6f85", Dark: "#a6adc8"}
-	accent := lipgloss.AdaptiveColor{Light: "#df8e1d", Dark: "#f9e2af"}
-	errorColor := lipgloss.AdaptiveColor{Light: "#d20f39", Dark: "#f38ba8"}
-	surface := lipgloss.AdaptiveColor{Light: "#ccd0da", Dark: "#313244"}
-
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(accent)
-	subtleStyle := lipgloss.NewStyle().Foreground(muted)
-	correctStyle := lipgloss.NewStyle().Foreground(base)
-	wrongStyle := lipgloss.NewStyle().Foreground(errorColor).Underline(true)
-	pendingStyle := lipgloss.NewStyle().Foreground(muted)
-	cursorStyle := lipgloss.NewStyle().Foreground(surface).Background(accent).Bold(true)
-	selectedStyle := lipgloss.NewStyle().Foreground(surface).Background(accent).Bold(true).Padding(0, 1)
-	cardStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(muted).Padding(1, 3)
+	styles := m.cfg.Theme.Styles()
+	titleStyle := styles.Title
+	subtleStyle := styles.Subtle
+	textStyle := styles.Text
+	errorStyle := styles.Error
+	selectedStyle := styles.Selected
+	cardStyle := styles.Card
 
+	keys := m.keys()
 	header := titleStyle.Render(appName)
 	contentWidth := m.width - 6
 	if contentWidth > 100 {
@@ -306,7 +664,8 @@ func (m model) View() string {
 		return layout.Render(content)
 	}
 
-	if m.showSplash {
+	switch m.screen() {
+	case screenSplash:
 		logo := titleStyle.Render(splashArt)
 		if compact {
 			logo = titleStyle.Render(appName)
@@ -314,16 +673,15 @@ func (m model) View() string {
 		body := strings.Join([]string{
 			logo,
 			"",
-			lipgloss.NewStyle().Foreground(base).Bold(true).Render("Terminal UI typing trainer"),
+			textStyle.Bold(true).Render("Terminal UI typing trainer"),
 			"",
 			selectedStyle.Render("Enter to Continue"),
 			"",
-			subtleStyle.Render("ctrl+c quit"),
+			subtleStyle.Render(keymap.HelpLine(keys.Select.WithDesc("continue").Help(), keys.Quit.Help())),
 		}, "\n")
 		return renderCentered(cardStyle.Width(contentWidth).Render(body))
-	}
 
-	if m.selectingMode {
+	case screenModes:
 		opts := make([]string, 0, len(m.modeLabels))
 		for i, label := range m.modeLabels {
 			s := subtleStyle.Render(label)
@@ -336,17 +694,107 @@ func (m model) View() string {
 		if compact {
 			line = strings.Join(opts, "\n")
 		}
+		next := selectedStyle.Render("Enter to Continue")
+		if m.textMissing() {
+			next = subtleStyle.Render("no text loaded: tuiper -file notes.txt or cat notes.txt | tuiper -")
+		}
 		content := strings.Join([]string{
 			header, titleStyle.Render("Select Mode"), "", line, "",
+			next, "",
+			subtleStyle.Render(keymap.HelpLine(menuHelp(keys, len(m.modeLabels)), keys.Heatmap.Help(), keys.Settings.Help(), keys.Back.WithDesc("back").Help(), keys.Quit.Help())),
+		}, "\n")
+		return renderCentered(cardStyle.Width(contentWidth).Render(content))
+
+	case screenHeatmap:
+		var body string
+		switch {
+		case m.heatErr != nil:
+			body = errorStyle.Render("history unavailable: " + m.heatErr.Error())
+		case m.heatSessions == 0:
+			body = subtleStyle.Render("no keystroke logs recorded yet")
+		default:
+			levels, empty := styles.Heat, styles.HeatEmpty
+			legend := make([]string, len(levels))
+			for i, l := range levels {
+				legend[i] = l.Render("  ")
+			}
+			body = strings.Join([]string{
+				subtleStyle.Render(fmt.Sprintf("%s across %d sessions  low ", m.heatMetric, m.heatSessions)) +
+					strings.Join(legend, "") + subtleStyle.Render(" high"),
+				"",
+				renderHeatmap(keyboardHeat(m.keyReport, m.heatMetric), levels, empty),
+				"",
+				textStyle.Render("weakest keys  ") + subtleStyle.Render(weakKeysLine(m.keyReport, m.heatMetric, 5)),
+				textStyle.Render("slowest pairs ") + subtleStyle.Render(slowBigramsLine(m.keyReport, 5)),
+			}, "\n")
+		}
+		content := strings.Join([]string{
+			header, titleStyle.Render("Weak Keys"), "", body, "",
+			subtleStyle.Render(keymap.HelpLine(keys.HeatmapMetric.Help(), keys.Back.WithDesc("back").Help(), keys.Quit.Help())),
+		}, "\n")
+		return renderCentered(cardStyle.Width(contentWidth).Render(content))
+
+	case screenSettings:
+		f := m.settings
+		labelWidth := 16
+		// The card pads the rows by 3 on each side.
+		valueWidth := max(contentWidth-labelWidth-8, 8)
+		rows := make([]string, 0, len(settingFields)+1)
+		for i, field := range settingFields {
+			label := fmt.Sprintf("%-*s", labelWidth, field.label)
+			value := f.settingValue(i, valueWidth)
+			switch {
+			case i == f.selected && f.editing:
+				value = lipgloss.NewStyle().Width(valueWidth).Render(string(f.input) + styles.Cursor.Render(" "))
+			case i == f.selected:
+				label = selectedStyle.Render(label)
+			default:
+				value = subtleStyle.Render(value)
+			}
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, label, "  ", value))
+		}
+		save := subtleStyle.Render("Save")
+		if f.selected == len(settingFields) {
+			save = selectedStyle.Render("Save")
+		}
+		rows = append(rows, "", save)
+		if f.err != nil {
+			rows = append(rows, "", errorStyle.Render(f.err.Error()))
+		}
+		help := keymap.HelpLine(keys.MenuPrev.HelpKey()+" "+keys.MenuNext.HelpKey(), keys.Select.WithDesc("edit").Help(), keys.Back.WithDesc("discard").Help(), keys.Quit.Help())
+		if f.editing {
+			help = keymap.HelpLine(keys.Select.WithDesc("apply").Help(), keys.Back.WithDesc("cancel").Help(), "lists are separated by spaces")
+		}
+		content := strings.Join([]string{
+			header, titleStyle.Render("Settings"), subtleStyle.Render(m.configPath), "",
+			strings.Join(rows, "\n"), "",
+			subtleStyle.Render(help),
+		}, "\n")
+		return renderCentered(cardStyle.Width(contentWidth).Render(content))
+
+	case screenLanguages:
+		opts := make([]string, 0, len(m.languages))
+		for i, lang := range m.languages {
+			s := subtleStyle.Render(lang.Label)
+			if i == m.selectedLang {
+				s = selectedStyle.Render(lang.Label)
+			}
+			opts = append(opts, s)
+		}
+		line := strings.Join(opts, "    ")
+		if compact {
+			line = strings.Join(opts, "\n")
+		}
+		content := strings.Join([]string{
+			header, titleStyle.Render("Select Language"), "", line, "",
 			selectedStyle.Render("Enter to Continue"), "",
-			subtleStyle.Render("arrows or " + quickPickHint(len(m.modeLabels)) + " • ctrl+c quit"),
+			subtleStyle.Render(keymap.HelpLine(menuHelp(keys, len(m.languages)), keys.Back.WithDesc("back").Help(), keys.Quit.Help())),
 		}, "\n")
 		return renderCentered(cardStyle.Width(contentWidth).Render(content))
-	}
 
-	if m.selectingTime {
-		opts := make([]string, 0, len(m.cfg.DurationLabels))
-		for i, label := range m.cfg.DurationLabels {
+	case screenLengths:
+		opts := make([]string, 0, len(m.lengthLabels))
+		for i, label := range m.lengthLabels {
 			s := subtleStyle.Render(label)
 			if i == m.selectedOption {
 				s = selectedStyle.Render(label)
@@ -358,65 +806,163 @@ func (m model) View() string {
 			line = strings.Join(opts, "\n")
 		}
 		content := strings.Join([]string{
-			header, titleStyle.Render("Select Duration"), "", line, "",
+			header, titleStyle.Render("Select Length"), "", line, "",
 			selectedStyle.Render("Enter to Start"), "",
-			subtleStyle.Render("arrows or " + quickPickHint(len(m.cfg.DurationOptions)) + " • ctrl+c quit"),
+			subtleStyle.Render(keymap.HelpLine(menuHelp(keys, len(m.lengths)), keys.Back.WithDesc("back").Help(), keys.Quit.Help())),
 		}, "\n")
 		return renderCentered(cardStyle.Width(contentWidth).Render(content))
 	}
 
-	rPrompt := []rune(m.prompt)
+	snap := m.session.Snapshot()
+	if m.done && m.replay == nil {
+		elapsed := m.elapsed(snap)
+		events := m.session.Events()
+		res := metrics.Compute(events, elapsed)
+		tl := metrics.PerSecond(events, elapsed)
+		summary := fmt.Sprintf("wpm %.0f   raw %.0f   acc %.1f%%   consistency %.0f%%   kps %.1f",
+			res.NetWPM, res.RawWPM, res.Accuracy, res.Consistency, res.KPS)
+		if compact {
+			summary = fmt.Sprintf("wpm %.0f  raw %.0f  acc %.0f%%", res.NetWPM, res.RawWPM, res.Accuracy)
+		}
+		chartHeight := 6
+		if compact {
+			chartHeight = 3
+		}
+		chart := renderChart([]chartSeries{
+			{values: tl.WPM, style: styles.Accent},
+			{values: tl.Raw, style: subtleStyle},
+		}, tl.Errors, contentWidth, chartHeight, subtleStyle, errorStyle)
+		details := []string{
+			titleStyle.Render("wpm") + subtleStyle.Render(" • raw • ") + errorStyle.Render("× errors"),
+			subtleStyle.Render(charsString(metrics.Breakdown(events, snap.Prompts, snap.Done))),
+			subtleStyle.Render(fmt.Sprintf("uncorrected errors %d", res.Uncorrected)),
+			subtleStyle.Render(summarizeKeystrokes(events).String()),
+			subtleStyle.Render(fmt.Sprintf("%s • %s • %.1fs", m.modeLabel(), m.length, elapsed.Seconds())),
+			subtleStyle.Render("test code " + m.testCode().String()),
+		}
+		if m.selectedMode == prompt.ModeText && m.text != nil {
+			details = append(details, subtleStyle.Render(fmt.Sprintf("%s • next chunk %d/%d", m.text.name, m.text.next+1, len(m.text.chunks))))
+			if m.textErr != nil {
+				details = append(details, errorStyle.Render("text progress not saved: "+m.textErr.Error()))
+			}
+		}
+		if m.saveErr != nil {
+			details = append(details, errorStyle.Render("history not saved: "+m.saveErr.Error()))
+		} else if m.savedID != "" {
+			details = append(details, subtleStyle.Render("saved as "+m.savedID))
+		}
+		content := strings.Join([]string{
+			header,
+			cardStyle.Width(contentWidth).Render(titleStyle.Render(summary)),
+			"",
+			chart,
+			"",
+			lipgloss.NewStyle().Width(contentWidth).Render(strings.Join(details, "\n")),
+			"",
+			subtleStyle.Render(keymap.HelpLine(keys.Select.WithDesc("menu").Help(), keys.Restart.Help(), keys.NewTest.Help(), keys.Quit.Help())),
+		}, "\n")
+		return renderCentered(content)
+	}
+
+	// Code is coloured by token class.
+	var classes []highlight.Class
+	if m.selectedMode == prompt.ModeCode {
+		classes = highlight.Classes(m.prompts.Language().Name, snap.Prompt)
+	}
+	styleOf := func(i int, typed bool) lipgloss.Style {
+		if i < len(classes) {
+			return styles.Code(classes[i], typed)
+		}
+		if typed {
+			return styles.Correct
+		}
+		return styles.Pending
+	}
+
+	ghostAt, showGhost := -1, false
+	if m.ghost != nil && snap.Started && !m.done {
+		ghostAt, showGhost = m.ghost.caret(snap)
+	}
 	var b strings.Builder
-	for i, r := range rPrompt {
+	for i, r := range []rune(snap.Prompt) {
+		glyph := string(r)
+		if r == '\n' {
+			glyph = returnMarker
+		}
 		switch {
-		case i < len(m.inputRunes):
-			if m.inputRunes[i] == r {
-				b.WriteString(correctStyle.Render(string(r)))
+		case showGhost && i == ghostAt && i != len(snap.Input):
+			b.WriteString(styles.Ghost.Render(glyph))
+		case i < len(snap.Input):
+			if snap.Input[i] == r {
+				b.WriteString(styleOf(i, true).Render(glyph))
 			} else {
-				b.WriteString(wrongStyle.Render(string(r)))
+				b.WriteString(styles.Wrong.Render(glyph))
 			}
-		case i == len(m.inputRunes) && !m.done:
-			b.WriteString(cursorStyle.Render(string(r)))
+		case i == len(snap.Input) && !m.done:
+			b.WriteString(styles.Cursor.Render(glyph))
 		default:
-			b.WriteString(pendingStyle.Render(string(r)))
+			b.WriteString(styleOf(i, false).Render(glyph))
+		}
+		if r == '\n' {
+			b.WriteString("\n")
 		}
 	}
+	text := b.String()
+	if snap.Paused {
+		// Hidden, so the prompt cannot be read ahead.
+		text = subtleStyle.Render("paused • " + keys.Pause.WithDesc("resume").Help())
+	}
 
-	elapsed := time.Second
-	if m.started {
-		if m.done {
-			elapsed = m.finishedAt.Sub(m.startedAt)
-		} else {
-			elapsed = time.Since(m.startedAt)
-		}
-		if elapsed <= 0 {
-			elapsed = time.Second
+	elapsed := m.elapsed(snap)
+	clock := elapsed
+	res := metrics.Compute(m.session.Events(), elapsed)
+	wpm, accuracy := res.NetWPM, res.Accuracy
+	if m.length.kind == history.KindTime {
+		clock = m.length.duration - elapsed
+		if m.done || clock < 0 {
+			clock = 0
 		}
 	}
-	wpm := float64(m.totalCorrect) / 5.0 / elapsed.Minutes()
-	accuracy := 100.0
-	if m.totalTyped > 0 {
-		accuracy = float64(m.totalCorrect) / float64(m.totalTyped) * 100.0
+	progress := fmt.Sprintf("time %.1fs", clock.Seconds())
+	compactProgress := fmt.Sprintf("t %.1fs", clock.Seconds())
+	if m.length.kind == history.KindWords {
+		progress = fmt.Sprintf("words %d/%d   %s", snap.Words, m.length.words, progress)
+		compactProgress = fmt.Sprintf("w %d/%d  %s", snap.Words, m.length.words, compactProgress)
 	}
-	remaining := m.sessionDuration - elapsed
-	if m.done || remaining < 0 {
-		remaining = 0
+	if m.selectedMode == prompt.ModeText && m.text != nil && m.replay == nil {
+		chunk := (m.text.next+len(snap.Prompts)-1)%len(m.text.chunks) + 1
+		progress = fmt.Sprintf("chunk %d/%d   %s", chunk, len(m.text.chunks), progress)
 	}
-	stats := fmt.Sprintf("mode %s   wpm %.0f   acc %.1f%%   chars %d   time %.1fs",
-		m.modeLabels[int(m.selectedMode)], wpm, accuracy, m.totalTyped, remaining.Seconds())
+	stats := fmt.Sprintf("mode %s   wpm %.0f   acc %.1f%%   chars %d   %s",
+		m.modeLabel(), wpm, accuracy, snap.Typed, progress)
 	if compact {
-		stats = fmt.Sprintf("wpm %.0f  acc %.0f%%  t %.1fs", wpm, accuracy, remaining.Seconds())
+		stats = fmt.Sprintf("wpm %.0f  acc %.0f%%  %s", wpm, accuracy, compactProgress)
 	}
-	footer := subtleStyle.Render("backspace edit • ctrl+c quit")
-	if m.done {
-		footer = subtleStyle.Render("enter menu • ctrl+c quit")
+	if m.ghost != nil && snap.Started {
+		stats += "\n" + m.ghost.delta(m.session, elapsed)
+	}
+	enter := ""
+	if strings.ContainsRune(snap.Prompt, '\n') {
+		enter = "enter " + returnMarker
+	}
+	pause := ""
+	if snap.Started && !snap.Paused {
+		pause = keys.Pause.Help()
+	}
+	footer := subtleStyle.Render(keymap.HelpLine("backspace edit", enter, pause, keys.Restart.Help(), keys.NewTest.Help(), keys.Back.Help(), keys.Quit.Help()))
+	switch {
+	case m.replay != nil && m.done:
+		footer = subtleStyle.Render(summarizeKeystrokes(m.session.Events()).String()) + "\n" +
+			subtleStyle.Render(m.replayHelp())
+	case m.replay != nil:
+		footer = subtleStyle.Render(m.replayHelp())
 	}
 
 	content := strings.Join([]string{
 		header,
 		cardStyle.Width(contentWidth).Render(titleStyle.Render(stats)),
 		"",
-		lipgloss.NewStyle().Width(contentWidth).Render(b.String()),
+		lipgloss.NewStyle().Width(contentWidth).Render(text),
 		"",
 		lipgloss.NewStyle().Width(contentWidth).Render(footer),
 	}, "\n")
@@ -424,28 +970,56 @@ func (m model) View() string {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := lookupCommand(os.Args[1]); ok {
+			os.Exit(cmd.run(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	flag.Usage = func() {
 		out := flag.CommandLine.Output()
 		fmt.Fprintf(out, "%s - terminal typing trainer\n\n", strings.ToLower(appName))
-		fmt.Fprintf(out, "Usage:\n  %s [options]\n\n", strings.ToLower(appName))
+		fmt.Fprintf(out, "Usage:\n  %s [options]\n  %s [options] -\n  %s <command> [options]\n\n", strings.ToLower(appName), strings.ToLower(appName), strings.ToLower(appName))
 		fmt.Fprintln(out, "Options:")
 		flag.PrintDefaults()
 		fmt.Fprintln(out, "")
+		printCommands(out)
+		fmt.Fprintln(out, "")
 		fmt.Fprintln(out, "Config file keys (JSON):")
 		fmt.Fprintln(out, `  "normal_words": ["word", ...]`)
 		fmt.Fprintln(out, `  "special_char_words": ["!@#$", ...]`)
 		fmt.Fprintln(out, `  "durations": ["15s", "30s", "1m", "2m"]`)
+		fmt.Fprintln(out, `  "word_counts": [10, 25, 50, 100]`)
 		fmt.Fprintln(out, `  "prompt_word_count": 18`)
 		fmt.Fprintln(out, `  "quote_endpoint": "https://dummyjson.com/quotes/random"`)
-		fmt.Fprintln(out, `  "go_example_endpoint": ""  # empty disables remote go examples`)
-		fmt.Fprintln(out, `  "go_examples": ["for i := 0; i < 3; i++ { fmt.Println(i) }", ...]`)
+		fmt.Fprintln(out, `  "code_examples": {"python": ["print(1)", ...], ...}  # go, python, javascript, typescript, rust, sql, shell`)
+		fmt.Fprintln(out, `  "code_endpoints": {"go": ""}  # empty disables remote snippets for a language`)
+		fmt.Fprintln(out, `  "code_dir": ""  # Go source tree to extract code practice snippets from`)
+		fmt.Fprintln(out, `  "seed": 0  # non-zero repeats the same prompts every test`)
+		fmt.Fprintln(out, `  "skip_indent": false  # true fills in code indentation after Enter`)
+		fmt.Fprintln(out, `  "theme": "catppuccin"  # high-contrast, monochrome, solarized, gruvbox or a custom theme`)
+		fmt.Fprintln(out, `  "themes": {"mine": {"base": "gruvbox", "pending": "#ffffff"}}`)
+		fmt.Fprintln(out, `  "keybindings": {"restart-test": ["ctrl+t"], ...}  # keys per action, see the man page`)
 		fmt.Fprintln(out, "")
 		fmt.Fprintf(out, "Man page:\n  %s -man\n", strings.ToLower(appName))
 	}
 
 	configPath := flag.String("config", defaultConfigPath(), "path to JSON config file")
+	historyPath := flag.String("history", defaultHistoryPath(), "path to session history file (empty disables saving)")
+	seed := flag.Int64("seed", 0, "seed for reproducible prompts (overrides the config \"seed\" key; 0 = random)")
+	code := flag.String("test", "", "start the test described by a test code (mode:length:seed)")
+	useGhost := flag.Bool("ghost", false, "race a ghost of your best saved run for the selected mode and length")
+	textFile := flag.String("file", "", "practice on the text of this file in the Custom Text mode (- reads stdin)")
+	codeDir := flag.String("code-dir", "", "practice Go code extracted from this source tree (overrides the config \"code_dir\" key)")
 	man := flag.Bool("man", false, "print the man page and exit")
 	flag.Parse()
+	switch {
+	case flag.NArg() == 1 && flag.Arg(0) == "-" && *textFile == "":
+		*textFile = "-"
+	case flag.NArg() > 0:
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	if *man {
 		fmt.Print(manPage)
@@ -458,7 +1032,64 @@ func main() {
 		os.Exit(1)
 	}
 
-	p := tea.NewProgram(initialModel(cfg), tea.WithAltScreen())
+	var store *history.Store
+	if *historyPath != "" {
+		store = history.NewStore(*historyPath)
+	}
+
+	if *seed != 0 {
+		cfg.Seed = *seed
+	}
+	if *codeDir != "" {
+		cfg.CodeDir = *codeDir
+	}
+	if cfg.CodeDir != "" {
+		snippets, err := prompt.GoSnippets(cfg.CodeDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "code dir: %v\n", err)
+			os.Exit(1)
+		}
+		// The tree replaces both the Go examples and a Go endpoint.
+		cfg.CodeExamples["go"] = snippets
+		delete(cfg.CodeEndpoints, "go")
+	}
+
+	m := initialModel(cfg, store)
+	m.configPath = *configPath
+	m.useGhost = *useGhost
+	if *textFile != "" {
+		text, err := openCustomText(*textFile, cfg.PromptWordCount)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "custom text: %v\n", err)
+			os.Exit(1)
+		}
+		progressPath := ""
+		if *historyPath != "" {
+			progressPath = textProgressPath(*historyPath)
+		}
+		if err := m.useText(text, progressPath); err != nil {
+			fmt.Fprintf(os.Stderr, "custom text: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *code != "" {
+		tc, err := parseTestCode(*code)
+		if err == nil && tc.mode == prompt.ModeText && m.text == nil {
+			err = fmt.Errorf("test code %q: custom text needs -file", *code)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(2)
+		}
+		m.startTest(tc)
+	}
+
+	opts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithReportFocus()}
+	if *textFile == "-" {
+		// stdin holds the text, so keys come from the terminal itself.
+		opts = append(opts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(m, opts...)
 	if _, err := p.Run(); err != nil {
 		panic(err)
 	}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"tuitype/internal/config"
+	"tuitype/internal/engine"
+	"tuitype/internal/history"
+	"tuitype/internal/prompt"
 )
 
 func TestPickIndexFromKey(t *testing.T) {
@@ -25,29 +32,305 @@ func TestQuickPickHint(t *testing.T) {
 }
 
 func TestMistypeThenImmediateCorrectionRepairsPreviousSlot(t *testing.T) {
-	m := model{prompt: "ab"}
+	m := model{session: engine.New("ab", nil, engine.Options{})}
 
 	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
 	m = updated.(model)
-	if got := string(m.inputRunes); got != "x" {
-		t.Fatalf("after wrong key, inputRunes = %q, want %q", got, "x")
+	snap := m.session.Snapshot()
+	if got := string(snap.Input); got != "x" {
+		t.Fatalf("after wrong key, input = %q, want %q", got, "x")
 	}
-	if m.totalTyped != 1 {
-		t.Fatalf("after wrong key, totalTyped = %d, want 1", m.totalTyped)
+	if snap.Typed != 1 {
+		t.Fatalf("after wrong key, Typed = %d, want 1", snap.Typed)
 	}
-	if m.totalCorrect != 0 {
-		t.Fatalf("after wrong key, totalCorrect = %d, want 0", m.totalCorrect)
+	if snap.Correct != 0 {
+		t.Fatalf("after wrong key, Correct = %d, want 0", snap.Correct)
 	}
 
 	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
 	m = updated.(model)
-	if got := string(m.inputRunes); got != "a" {
-		t.Fatalf("after correction, inputRunes = %q, want %q", got, "a")
+	snap = m.session.Snapshot()
+	if got := string(snap.Input); got != "a" {
+		t.Fatalf("after correction, input = %q, want %q", got, "a")
+	}
+	if snap.Typed != 2 {
+		t.Fatalf("after correction, Typed = %d, want 2", snap.Typed)
+	}
+	if snap.Correct != 1 {
+		t.Fatalf("after correction, Correct = %d, want 1", snap.Correct)
+	}
+}
+
+func TestSessionRecordIncludesTypedPrompts(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	session := engine.New("first", func(string) string { return "next" }, engine.Options{})
+	for _, r := range "firstne" {
+		session.Type(r, start)
+	}
+	m := model{
+		session:    session,
+		length:     timeLength(time.Minute),
+		finishedAt: start.Add(time.Minute),
+		done:       true,
+	}
+	rec := m.sessionRecord()
+	if len(rec.Prompts) != 2 || rec.Prompts[1] != "next" {
+		t.Fatalf("Prompts = %q, want [first next]", rec.Prompts)
+	}
+	if rec.TotalTyped != 7 || rec.TotalCorrect != 7 {
+		t.Fatalf("TotalTyped/TotalCorrect = %d/%d, want 7/7", rec.TotalTyped, rec.TotalCorrect)
+	}
+	if rec.WPM != 1.4 || rec.Accuracy != 100 {
+		t.Fatalf("WPM/Accuracy = %.1f/%.1f, want 1.4/100", rec.WPM, rec.Accuracy)
 	}
-	if m.totalTyped != 2 {
-		t.Fatalf("after correction, totalTyped = %d, want 2", m.totalTyped)
+	if rec.Mode != "normal" {
+		t.Fatalf("Mode = %q, want %q", rec.Mode, "normal")
 	}
-	if m.totalCorrect != 1 {
-		t.Fatalf("after correction, totalCorrect = %d, want 1", m.totalCorrect)
+}
+
+func TestWordTestEndsOnFinalCorrectCharacter(t *testing.T) {
+	length := testLength{kind: history.KindWords, words: 2}
+	var tm tea.Model = model{
+		session: engine.New("ab cd ef", nil, engineOptions(prompt.ModeNormal, length)),
+		length:  length,
+	}
+	for _, k := range []string{"a", "b", " ", "c"} {
+		tm, _ = tm.Update(keyMsg(k))
+	}
+	if tm.(model).done {
+		t.Fatal("word test ended before the final character")
+	}
+	tm, _ = tm.Update(keyMsg("d"))
+	m := tm.(model)
+	snap := m.session.Snapshot()
+	if !m.done || !m.finishedAt.Equal(snap.FinishedAt) {
+		t.Fatalf("done = %v, finishedAt = %v, want done at %v", m.done, m.finishedAt, snap.FinishedAt)
+	}
+	rec := m.sessionRecord()
+	if rec.Kind != history.KindWords || rec.Words != 2 || rec.Duration != snap.FinishedAt.Sub(snap.StartedAt) {
+		t.Fatalf("record = %+v, want 2 words lasting the actual elapsed time", rec)
+	}
+	if len(rec.Prompts) != 1 || rec.Prompts[0] != "ab cd" {
+		t.Fatalf("Prompts = %q, want [ab cd]", rec.Prompts)
+	}
+}
+
+func TestEnterTypesNewlineInCodePrompts(t *testing.T) {
+	length := testLength{kind: history.KindPrompt}
+	opts := engineOptions(prompt.ModeCode, length)
+	opts.SkipIndent = true
+	var tm tea.Model = model{
+		session: engine.New("if x {\n    y()\n}", nil, opts),
+		length:  length,
+	}
+	// Tab types nothing, not an error.
+	for _, k := range []string{"if x {", "enter", "tab", "y()", "enter", "}"} {
+		tm, _ = tm.Update(keyMsg(k))
+	}
+	m := tm.(model)
+	if snap := m.session.Snapshot(); !m.done || snap.Correct != snap.Typed {
+		t.Fatalf("done = %v, input %q with %d/%d correct", m.done, string(snap.Input), snap.Correct, snap.Typed)
+	}
+}
+
+func TestEnterIsIgnoredInSingleLinePrompts(t *testing.T) {
+	length := testLength{kind: history.KindPrompt}
+	var tm tea.Model = model{
+		session: engine.New("ab cd", nil, engineOptions(prompt.ModeNormal, length)),
+		length:  length,
+	}
+	for _, k := range []string{"ab", "enter", " cd"} {
+		tm, _ = tm.Update(keyMsg(k))
+	}
+	m := tm.(model)
+	if snap := m.session.Snapshot(); !m.done || snap.Typed != 5 || snap.Correct != 5 {
+		t.Fatalf("done = %v, %d/%d correct; Enter must not count as a keystroke", m.done, snap.Correct, snap.Typed)
+	}
+}
+
+func TestCodeModeAsksForLanguage(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	cfg.CodeExamples["python"] = []string{`print("hi")`}
+	m := initialModel(cfg, nil)
+	m.screens = []screen{screenModes}
+	m.selectedMode = prompt.ModeCode
+
+	var tm tea.Model = m
+	for _, k := range []string{"enter", "2", "enter"} {
+		tm, _ = tm.Update(keyMsg(k))
+	}
+	m = tm.(model)
+	if m.screen() != screenLengths {
+		t.Fatalf("screen = %v, want the length menu", m.screen())
+	}
+	if got := m.prompts.Language().Name; got != "python" {
+		t.Fatalf("language = %q, want python", got)
+	}
+	tm, _ = tm.Update(keyMsg("enter"))
+	m = tm.(model)
+	if got := m.session.Snapshot().Prompt; got != `print("hi")` {
+		t.Fatalf("prompt = %q, want the python example", got)
+	}
+	if got := m.sessionRecord().Language; got != "python" {
+		t.Fatalf("record language = %q, want python", got)
+	}
+}
+
+func TestRestartAndBackKeys(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	m := initialModel(cfg, nil)
+
+	var tm tea.Model = m
+	for _, k := range []string{"enter", "j", "k", "enter", "enter", "x"} {
+		tm, _ = tm.Update(keyMsg(k))
+	}
+	m = tm.(model)
+	if m.selectedMode != prompt.ModeNormal || m.screen() != screenTest {
+		t.Fatalf("mode %v on screen %v, want a normal test", m.selectedMode, m.screen())
+	}
+	first := m.session.Snapshot().Prompt
+
+	tm, _ = tm.Update(keyMsg("ctrl+r"))
+	m = tm.(model)
+	if snap := m.session.Snapshot(); snap.Prompt != first || snap.Typed != 0 {
+		t.Fatalf("after restart prompt = %q with %d typed, want %q from the start", snap.Prompt, snap.Typed, first)
+	}
+
+	tm, _ = tm.Update(keyMsg("ctrl+n"))
+	m = tm.(model)
+	if m.session.Snapshot().Prompt == first {
+		t.Fatal("ctrl+n should start a test on a new prompt")
+	}
+
+	tm, _ = tm.Update(keyMsg("esc"))
+	if m = tm.(model); m.screen() != screenModes || m.session != nil {
+		t.Fatalf("screen = %v, want the mode menu with the test abandoned", m.screen())
+	}
+}
+
+func TestPauseHidesPromptAndStopsClock(t *testing.T) {
+	length := timeLength(time.Minute)
+	var tm tea.Model = model{
+		session:    engine.New("abc def", nil, engineOptions(prompt.ModeNormal, length)),
+		length:     length,
+		modeLabels: prompt.ModeLabels(),
+		width:      80,
+		height:     24,
+	}
+	tm, _ = tm.Update(keyMsg("a"))
+	tm, _ = tm.Update(tea.BlurMsg{})
+	m := tm.(model)
+	if !m.session.Snapshot().Paused {
+		t.Fatal("losing focus should pause the test")
+	}
+	if view := m.View(); strings.Contains(view, "abc") || !strings.Contains(view, "paused") {
+		t.Fatalf("paused view shows the prompt:\n%s", view)
+	}
+	tm, _ = tm.Update(keyMsg("b"))
+	if got := string(tm.(model).session.Snapshot().Input); got != "a" {
+		t.Fatalf("input = %q, want keys ignored while paused", got)
+	}
+	tm, _ = tm.Update(keyMsg("ctrl+p"))
+	tm, _ = tm.Update(keyMsg("b"))
+	if snap := tm.(model).session.Snapshot(); snap.Paused || string(snap.Input) != "ab" {
+		t.Fatalf("paused %v, input %q; want typing to resume", snap.Paused, string(snap.Input))
+	}
+
+	start := time.Now().Add(-time.Hour)
+	m.session = engine.New("abc", nil, engineOptions(prompt.ModeNormal, length))
+	m.session.Type('a', start)
+	m.session.Pause(start.Add(5 * time.Second))
+	if got := m.elapsed(m.session.Snapshot()); got != 5*time.Second {
+		t.Fatalf("elapsed = %v, want the 5s before the pause", got)
+	}
+}
+
+func TestBackWalksTheScreenStack(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	var tm tea.Model = initialModel(cfg, nil)
+	for _, k := range []string{"enter", "4", "enter", "enter"} {
+		tm, _ = tm.Update(keyMsg(k))
+	}
+	if got := tm.(model).screen(); got != screenLengths {
+		t.Fatalf("screen = %v, want the length menu of code practice", got)
+	}
+	for _, want := range []screen{screenLanguages, screenModes, screenSplash, screenSplash} {
+		tm, _ = tm.Update(keyMsg("esc"))
+		if got := tm.(model).screen(); got != want {
+			t.Fatalf("after esc screen = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLengthOptions(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	lengths, labels := lengthOptions(cfg)
+	want := []string{"15s", "30s", "1m", "2m", "10 words", "25 words", "50 words", "100 words", "1 prompt"}
+	if strings.Join(labels, ",") != strings.Join(want, ",") {
+		t.Fatalf("labels = %q, want %q", labels, want)
+	}
+	if len(lengths) != len(labels) || lengths[5].words != 25 || lengths[8].kind != history.KindPrompt {
+		t.Fatalf("lengths = %+v", lengths)
+	}
+}
+
+func TestSummarizeKeystrokes(t *testing.T) {
+	at := func(ms int) time.Duration { return time.Duration(ms) * time.Millisecond }
+	events := []engine.Event{
+		{Kind: engine.KindCorrect, Expected: 'a', Offset: at(0)},
+		{Kind: engine.KindCorrect, Expected: 'b', Offset: at(100)},
+		{Kind: engine.KindIncorrect, Expected: 'a', Offset: at(200)},
+		{Kind: engine.KindCorrection, Expected: 'a', Offset: at(300)},
+		{Kind: engine.KindCorrect, Expected: 'b', Offset: at(700)},
+		{Kind: engine.KindBackspace, Expected: 'b', Offset: at(800)},
+		{Kind: engine.KindCorrect, Expected: 'b', Offset: at(900)},
+	}
+	got := summarizeKeystrokes(events)
+	if got.keys != 6 || got.errors != 1 || got.corrections != 1 || got.backspaces != 1 {
+		t.Fatalf("summary = %+v", got)
+	}
+	if got.slowest.Key != 'b' || got.slowest.AvgLatency() != at(200) {
+		t.Fatalf("slowest = %q %v, want 'b' 200ms", got.slowest.Key, got.slowest.AvgLatency())
+	}
+	if got.missed.Key != 'a' || !strings.Contains(got.String(), "most missed 'a' 33%") {
+		t.Fatalf("summary line = %q, want 'a' most missed at 33%%", got.String())
+	}
+}
+
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "right":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	case "left":
+		return tea.KeyMsg{Type: tea.KeyLeft}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "ctrl+r":
+		return tea.KeyMsg{Type: tea.KeyCtrlR}
+	case "ctrl+n":
+		return tea.KeyMsg{Type: tea.KeyCtrlN}
+	case "ctrl+p":
+		return tea.KeyMsg{Type: tea.KeyCtrlP}
+	case "ctrl+a":
+		return tea.KeyMsg{Type: tea.KeyCtrlA}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
 	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
 }
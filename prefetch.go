@@ -0,0 +1,69 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tuitype/internal/prompt"
+)
+
+// prefetchSize is how many remote prompts are kept ready per mode.
+const prefetchSize = 3
+
+// promptMsg delivers a prompt fetched in the background. lang is the code
+// language it was fetched for.
+type promptMsg struct {
+	mode prompt.Mode
+	lang string
+	text string
+	err  error
+}
+
+func fetchPromptCmd(s *prompt.Service, mode prompt.Mode) tea.Cmd {
+	return func() tea.Msg {
+		lang := s.Language().Name
+		text, err := s.Fetch(mode, "")
+		return promptMsg{mode: mode, lang: lang, text: text, err: err}
+	}
+}
+
+// selectLanguage switches the code mode to the language at index i and
+// drops the snippets prefetched for the previous one.
+func (m *model) selectLanguage(i int) tea.Cmd {
+	m.selectedLang = i
+	if m.prompts.Language().Name == m.languages[i].Name {
+		return nil
+	}
+	m.prompts.SetLanguage(m.languages[i].Name)
+	m.queue.Reset(prompt.ModeCode)
+	return m.prefetch()
+}
+
+// prefetch starts background fetches to refill the queue of the selected
+// mode. Local modes generate prompts instantly and are never queued.
+func (m model) prefetch() tea.Cmd {
+	if m.queue == nil || !m.prompts.Remote(m.selectedMode) {
+		return nil
+	}
+	n := m.queue.Claim(m.selectedMode)
+	cmds := make([]tea.Cmd, 0, n)
+	for i := 0; i < n; i++ {
+		cmds = append(cmds, fetchPromptCmd(m.prompts, m.selectedMode))
+	}
+	return tea.Batch(cmds...)
+}
+
+// nextPromptFunc returns the prompt source for a session in mode. Remote
+// modes take from the prefetch queue and fall back to a local prompt when
+// it is empty, so a rollover never waits on the network.
+func (m model) nextPromptFunc(mode prompt.Mode) func(previous string) string {
+	prompts, queue := m.prompts, m.queue
+	if queue == nil || !prompts.Remote(mode) {
+		return func(previous string) string { return prompts.Next(mode, previous) }
+	}
+	return func(previous string) string {
+		if p, ok := queue.Pop(mode, previous); ok {
+			return p
+		}
+		return prompts.Fallback(mode, previous)
+	}
+}
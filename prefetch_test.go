@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tuitype/internal/config"
+	"tuitype/internal/prompt"
+)
+
+func quoteModel(t *testing.T, endpoint string) model {
+	t.Helper()
+	app := config.Default()
+	app.QuoteEndpoint = endpoint
+	cfg, err := config.Resolve(app)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	m := initialModel(cfg, nil)
+	m.selectedMode = prompt.ModeQuote
+	return m
+}
+
+func TestRolloverDoesNotWaitOnSlowEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	m := quoteModel(t, srv.URL)
+	start := time.Now()
+	m.resetSession()
+	next := m.nextPromptFunc(prompt.ModeQuote)
+	if p := next(m.session.Snapshot().Prompt); p == "" {
+		t.Fatal("expected a fallback prompt")
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Fatalf("rollover blocked for %v", waited)
+	}
+}
+
+func TestPrefetchedPromptIsUsedOnRollover(t *testing.T) {
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		fmt.Fprintf(w, `{"content":"remote quote %d"}`, n)
+	}))
+	defer srv.Close()
+
+	m := quoteModel(t, srv.URL)
+	if claimed := m.queue.Claim(prompt.ModeQuote); claimed != prefetchSize {
+		t.Fatalf("Claim = %d, want %d", claimed, prefetchSize)
+	}
+	for i := 0; i < prefetchSize; i++ {
+		updated, _ := m.Update(fetchPromptCmd(m.prompts, prompt.ModeQuote)())
+		m = updated.(model)
+	}
+	if got := m.queue.Len(prompt.ModeQuote); got != prefetchSize {
+		t.Fatalf("queued = %d, want %d", got, prefetchSize)
+	}
+
+	m.resetSession()
+	if got := m.session.Snapshot().Prompt; got != "remote quote 1" {
+		t.Fatalf("first prompt = %q, want %q", got, "remote quote 1")
+	}
+	if cmd := m.prefetch(); cmd == nil {
+		t.Fatal("expected a refill fetch after consuming a prompt")
+	}
+}
+
+func TestLocalModesAreNotPrefetched(t *testing.T) {
+	m := quoteModel(t, "")
+	m.selectedMode = prompt.ModeNormal
+	if cmd := m.prefetch(); cmd != nil {
+		t.Fatal("normal mode should not prefetch")
+	}
+}
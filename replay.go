@@ -0,0 +1,278 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tuitype/internal/config"
+	"tuitype/internal/engine"
+	"tuitype/internal/history"
+	"tuitype/internal/keymap"
+	"tuitype/internal/prompt"
+)
+
+var replaySpeeds = []float64{0.25, 0.5, 1, 2, 4}
+
+const replayDefaultSpeed = 2 // index of 1x in replaySpeeds
+
+// replayer feeds a recorded keystroke log back into an engine.Session on a
+// virtual clock, so the regular typing view renders the run as it happened.
+type replayer struct {
+	rec      history.Session
+	opts     engine.Options
+	next     int
+	clock    time.Duration
+	speed    int
+	paused   bool
+	lastTick time.Time
+}
+
+func newReplayer(rec history.Session, opts engine.Options) *replayer {
+	// The log, not the current config, tells whether indentation was
+	// skipped when the run was recorded.
+	opts.SkipIndent = false
+	for _, ev := range rec.Keystrokes {
+		if ev.Kind == engine.KindSkip {
+			opts.SkipIndent = true
+			break
+		}
+	}
+	return &replayer{rec: rec, opts: opts, speed: replayDefaultSpeed}
+}
+
+// length is the recorded elapsed time of the run.
+func (r *replayer) length() time.Duration {
+	if d := r.rec.FinishedAt.Sub(r.rec.StartedAt) - r.rec.Paused; d > 0 {
+		return d
+	}
+	if n := len(r.rec.Keystrokes); n > 0 {
+		return r.rec.Keystrokes[n-1].Offset
+	}
+	return 0
+}
+
+// newSession rewinds the replay and returns a session that serves the
+// recorded prompts in order.
+func (r *replayer) newSession() *engine.Session {
+	r.next = 0
+	r.clock = 0
+	r.lastTick = time.Time{}
+	prompts := r.rec.Prompts
+	first := ""
+	if len(prompts) > 0 {
+		first = prompts[0]
+	}
+	served := 0
+	return engine.New(first, func(previous string) string {
+		if served+1 < len(prompts) {
+			served++
+			return prompts[served]
+		}
+		return previous
+	}, r.opts)
+}
+
+// tick moves the virtual clock by the wall time since the previous tick,
+// scaled by the playback speed, and applies every event that is now due.
+func (r *replayer) tick(now time.Time, s *engine.Session) {
+	if !r.lastTick.IsZero() && !r.paused {
+		r.clock += time.Duration(float64(now.Sub(r.lastTick)) * replaySpeeds[r.speed])
+	}
+	r.lastTick = now
+	r.seek(r.clock, s)
+}
+
+// seek moves the clock to clock and applies every event due by then.
+func (r *replayer) seek(clock time.Duration, s *engine.Session) {
+	r.clock = clock
+	for r.next < len(r.rec.Keystrokes) && r.rec.Keystrokes[r.next].Offset <= r.clock {
+		r.apply(s)
+	}
+}
+
+// step applies exactly one keystroke and moves the clock to it.
+func (r *replayer) step(s *engine.Session) {
+	for r.next < len(r.rec.Keystrokes) && r.rec.Keystrokes[r.next].Kind == engine.KindSkip {
+		r.next++
+	}
+	if r.next < len(r.rec.Keystrokes) {
+		r.clock = r.rec.Keystrokes[r.next].Offset
+		r.apply(s)
+	}
+}
+
+func (r *replayer) apply(s *engine.Session) {
+	ev := r.rec.Keystrokes[r.next]
+	r.next++
+	at := r.rec.StartedAt.Add(ev.Offset)
+	switch ev.Kind {
+	case engine.KindBackspace:
+		s.Backspace(at)
+	case engine.KindSkip:
+		// The session skips the indentation again by itself.
+	default:
+		s.Type(ev.Rune, at)
+	}
+}
+
+// position is the replay time since the first keystroke.
+func (r *replayer) position() time.Duration {
+	if r.clock > r.length() {
+		return r.length()
+	}
+	return r.clock
+}
+
+func (r *replayer) status() string {
+	state := "playing"
+	if r.paused {
+		state = "paused"
+	}
+	return fmt.Sprintf("replay %s %gx", state, replaySpeeds[r.speed])
+}
+
+// The playback controls of a replay. Nothing is typed during a replay, so
+// they use plain keys and are not configurable.
+var (
+	replayPause  = keymap.NewBinding("pause", " ")
+	replaySlower = keymap.NewBinding("slower", "-")
+	replayFaster = keymap.NewBinding("faster", "+", "=")
+	replayStep   = keymap.NewBinding("step", "right", ".")
+)
+
+// replayHelp is the footer of a replay.
+func (m model) replayHelp() string {
+	keys := m.keys()
+	exit := keys.Back.WithDesc("exit").Help()
+	if m.done {
+		return keymap.HelpLine("replay finished", keys.Select.WithDesc("replay again").Help(), exit, keys.Quit.Help())
+	}
+	pause := keymap.NewBinding("pause", append(replayPause.Keys(), keys.Pause.Keys()...)...)
+	speed := replaySlower.HelpKey() + " " + replayFaster.HelpKey() + " speed"
+	return keymap.HelpLine(m.replay.status(), pause.Help(), speed, replayStep.Help(), exit, keys.Quit.Help())
+}
+
+func (m model) updateReplayKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	keys := m.keys()
+	switch {
+	case keymap.Matches(msg, keys.Quit, keys.Back):
+		return m, tea.Quit
+	case keymap.Matches(msg, replayPause, keys.Pause):
+		m.replay.paused = !m.replay.paused
+	case keymap.Matches(msg, replaySlower):
+		if m.replay.speed > 0 {
+			m.replay.speed--
+		}
+	case keymap.Matches(msg, replayFaster):
+		if m.replay.speed < len(replaySpeeds)-1 {
+			m.replay.speed++
+		}
+	case keymap.Matches(msg, replayStep):
+		if !m.done {
+			m.replay.paused = true
+			m.replay.step(m.session)
+		}
+	case keymap.Matches(msg, keys.Select):
+		if m.done {
+			m.session = m.replay.newSession()
+			m.done = false
+			m.finishedAt = time.Time{}
+		}
+	}
+	return m, nil
+}
+
+func newReplayModel(cfg config.RuntimeConfig, rec history.Session) model {
+	m := initialModel(cfg, nil)
+	m.screens = []screen{screenTest}
+	if mode, ok := prompt.ModeByName(rec.Mode); ok {
+		m.selectedMode = mode
+	}
+	if rec.Language != "" {
+		m.prompts.SetLanguage(rec.Language)
+	}
+	m.length = lengthOf(rec)
+	m.replay = newReplayer(rec, engineOptions(m.selectedMode, m.length))
+	if m.length.kind == history.KindTime {
+		m.length.duration = m.replay.length()
+	}
+	m.session = m.replay.newSession()
+	return m
+}
+
+// findReplay resolves a replay argument: a path to a history file holding a
+// single session, a session id from the history store, or "last".
+func findReplay(arg, historyPath string) (history.Session, error) {
+	if _, err := os.Stat(arg); err == nil {
+		sessions, err := history.NewStore(arg).Load()
+		if err != nil {
+			return history.Session{}, err
+		}
+		if len(sessions) != 1 {
+			return history.Session{}, fmt.Errorf("%s holds %d sessions; pass one session id with -history %s", arg, len(sessions), arg)
+		}
+		return sessions[0], nil
+	}
+
+	sessions, err := history.NewStore(historyPath).Load()
+	if err != nil {
+		return history.Session{}, err
+	}
+	if arg == "last" && len(sessions) > 0 {
+		return sessions[len(sessions)-1], nil
+	}
+	if rec, ok := history.Find(sessions, arg); ok {
+		return rec, nil
+	}
+	return history.Session{}, fmt.Errorf("no session %q in %s", arg, historyPath)
+}
+
+func runReplay(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", defaultConfigPath(), "path to JSON config file")
+	historyPath := fs.String("history", defaultHistoryPath(), "path to session history file")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:\n  %s replay [options] <id|last|file>\n\nOptions:\n", strings.ToLower(appName))
+		fs.PrintDefaults()
+		fmt.Fprintln(stderr, "\nKeys: space pause • - slower • + faster • → step • enter restart • q quit")
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	rec, err := findReplay(fs.Arg(0), *historyPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "replay: %v\n", err)
+		return 1
+	}
+	if len(rec.Keystrokes) == 0 {
+		fmt.Fprintf(stderr, "replay: session %s has no keystroke log\n", rec.ID)
+		return 1
+	}
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "config error: %v\n", err)
+		return 1
+	}
+
+	p := tea.NewProgram(newReplayModel(cfg, rec), tea.WithAltScreen(), tea.WithOutput(stdout))
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(stderr, "replay: %v\n", err)
+		return 1
+	}
+	return 0
+}
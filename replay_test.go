@@ -0,0 +1,139 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tuitype/internal/config"
+	"tuitype/internal/engine"
+	"tuitype/internal/history"
+)
+
+func recordedSession(t *testing.T) history.Session {
+	t.Helper()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := engine.New("ab", func(string) string { return "cd" }, engine.Options{})
+	for i, r := range "axbc" {
+		at := start.Add(time.Duration(i) * time.Second)
+		if r == 'x' {
+			s.Type(r, at)
+			s.Backspace(at.Add(500 * time.Millisecond))
+			continue
+		}
+		s.Type(r, at)
+	}
+	snap := s.Snapshot()
+	return history.Session{
+		ID:         history.NewID(start),
+		StartedAt:  start,
+		FinishedAt: start.Add(4 * time.Second),
+		Mode:       "normal",
+		Prompts:    snap.Prompts,
+		Keystrokes: s.Events(),
+	}
+}
+
+func TestReplayFollowsVirtualClock(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	m := newReplayModel(cfg, recordedSession(t))
+	wall := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	tick := func(d time.Duration) {
+		wall = wall.Add(d)
+		updated, _ := m.Update(tickMsg(wall))
+		m = updated.(model)
+	}
+
+	tick(0)
+	if got := string(m.session.Snapshot().Input); got != "a" {
+		t.Fatalf("at 0s input = %q, want %q", got, "a")
+	}
+	tick(1200 * time.Millisecond)
+	if got := string(m.session.Snapshot().Input); got != "ax" {
+		t.Fatalf("at 1.2s input = %q, want %q", got, "ax")
+	}
+	tick(1300 * time.Millisecond)
+	snap := m.session.Snapshot()
+	if snap.Prompt != "ab" || string(snap.Input) != "ab" {
+		t.Fatalf("at 2.5s snapshot = %+v, want prompt ab input ab", snap)
+	}
+	tick(2 * time.Second)
+	if snap := m.session.Snapshot(); snap.Prompt != "cd" || string(snap.Input) != "c" {
+		t.Fatalf("at 4.5s snapshot = %+v, want prompt cd input c", snap)
+	}
+	if !m.done {
+		t.Fatal("replay should be done after the recorded length")
+	}
+	m.history = history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	if cmd := m.finish(wall); cmd != nil {
+		t.Fatal("a finished replay must not be saved")
+	}
+}
+
+func TestReplayStepAndSpeed(t *testing.T) {
+	cfg, _ := config.Resolve(config.Default())
+	m := newReplayModel(cfg, recordedSession(t))
+	press := func(key string) {
+		updated, _ := m.updateReplayKey(keyMsg(key))
+		m = updated.(model)
+	}
+	press("right")
+	press("right")
+	if !m.replay.paused || string(m.session.Snapshot().Input) != "ax" {
+		t.Fatalf("after two steps: paused=%v input=%q", m.replay.paused, string(m.session.Snapshot().Input))
+	}
+	press("+")
+	if got := replaySpeeds[m.replay.speed]; got != 2 {
+		t.Fatalf("speed = %v, want 2", got)
+	}
+	press("-")
+	press("-")
+	if got := replaySpeeds[m.replay.speed]; got != 0.5 {
+		t.Fatalf("speed = %v, want 0.5", got)
+	}
+}
+
+func TestFindReplayByID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	rec := recordedSession(t)
+	if err := history.NewStore(path).Append(rec); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	got, err := findReplay(rec.ID, path)
+	if err != nil || got.ID != rec.ID {
+		t.Fatalf("findReplay(id) = %v, %v", got.ID, err)
+	}
+	got, err = findReplay(path, "")
+	if err != nil || got.ID != rec.ID {
+		t.Fatalf("findReplay(file) = %v, %v", got.ID, err)
+	}
+	if _, err := findReplay("missing", path); err == nil {
+		t.Fatal("expected error for unknown id")
+	}
+}
+
+func TestReplayKeysFollowKeyMap(t *testing.T) {
+	fc := config.Default()
+	fc.Keybindings = map[string][]string{"back-to-menu": {"ctrl+b"}, "pause": {"ctrl+s"}}
+	cfg, err := config.Resolve(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newReplayModel(cfg, recordedSession(t))
+	if help := m.replayHelp(); !strings.Contains(help, "space/ctrl+s pause") || !strings.Contains(help, "ctrl+b exit") {
+		t.Fatalf("replay help = %q", help)
+	}
+	if _, cmd := m.updateReplayKey(keyMsg("esc")); cmd != nil {
+		t.Fatal("esc should not quit once back-to-menu is rebound")
+	}
+	updated, _ := m.updateReplayKey(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if !updated.(model).replay.paused {
+		t.Fatal("the pause binding should pause a replay")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"tuitype/internal/engine"
+	"tuitype/internal/metrics"
+)
+
+// keystrokeSummary condenses a session's keystroke log into the one-line
+// breakdown shown once a test is done.
+type keystrokeSummary struct {
+	keys        int
+	errors      int
+	corrections int
+	backspaces  int
+	// slowest and missed are the slowest and most missed keys; a zero Key
+	// means no key qualified.
+	slowest metrics.KeyStat
+	missed  metrics.KeyStat
+}
+
+// summaryKeyMinSamples keeps a single hesitation or slip from being
+// reported as the slowest or most missed key.
+const summaryKeyMinSamples = 2
+
+func summarizeKeystrokes(events []engine.Event) keystrokeSummary {
+	var sum keystrokeSummary
+	for _, ev := range events {
+		switch ev.Kind {
+		case engine.KindBackspace:
+			sum.backspaces++
+		case engine.KindIncorrect, engine.KindExtra:
+			sum.keys++
+			sum.errors++
+		case engine.KindCorrection:
+			sum.keys++
+			sum.corrections++
+		case engine.KindCorrect:
+			sum.keys++
+		}
+	}
+
+	var report metrics.KeyReport
+	report.Add(events)
+	if slow := report.SlowestKeys(summaryKeyMinSamples); len(slow) > 0 {
+		sum.slowest = slow[0]
+	}
+	if worst := report.WorstKeys(summaryKeyMinSamples); len(worst) > 0 && worst[0].Errors > 0 {
+		sum.missed = worst[0]
+	}
+	return sum
+}
+
+func (s keystrokeSummary) String() string {
+	parts := []string{
+		fmt.Sprintf("keys %d", s.keys),
+		fmt.Sprintf("errors %d", s.errors),
+		fmt.Sprintf("corrections %d", s.corrections),
+		fmt.Sprintf("backspaces %d", s.backspaces),
+	}
+	if s.slowest.Key != 0 {
+		parts = append(parts, fmt.Sprintf("slowest %s %dms", keyName(s.slowest.Key), s.slowest.AvgLatency().Milliseconds()))
+	}
+	if s.missed.Key != 0 {
+		parts = append(parts, fmt.Sprintf("most missed %s %.0f%%", keyName(s.missed.Key), s.missed.ErrorRate()))
+	}
+	return strings.Join(parts, " • ")
+}
+
+func keyName(r rune) string {
+	switch r {
+	case ' ':
+		return "space"
+	case '\n':
+		return "enter"
+	}
+	return fmt.Sprintf("%q", r)
+}
+
+func charsString(c metrics.Chars) string {
+	return fmt.Sprintf("characters %d/%d/%d/%d (correct/incorrect/extra/missed)", c.Correct, c.Incorrect, c.Extra, c.Missed)
+}
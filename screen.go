@@ -0,0 +1,52 @@
+package main
+
+// screen is one screen of the UI. The model keeps the screens it went
+// through on a stack: choosing an entry pushes the next screen and the
+// back key pops it again.
+type screen int
+
+const (
+	screenSplash screen = iota
+	screenModes
+	screenHeatmap
+	screenSettings
+	screenLanguages
+	screenLengths
+	// screenTest shows the typing session and, once it is done, its results.
+	screenTest
+)
+
+// screen returns the screen on top of the stack. Models built without one
+// show the test, so a model with just a session can be typed into.
+func (m model) screen() screen {
+	if len(m.screens) == 0 {
+		return screenTest
+	}
+	return m.screens[len(m.screens)-1]
+}
+
+// push shows s on top of the current screen.
+func (m *model) push(s screen) {
+	// Copy rather than append in place: earlier copies of the model share
+	// the array.
+	m.screens = append(m.screens[:len(m.screens):len(m.screens)], s)
+}
+
+// back returns to the previous screen. The first screen stays.
+func (m *model) back() {
+	if len(m.screens) > 1 {
+		m.screens = m.screens[:len(m.screens)-1]
+	}
+}
+
+// backTo pops the screens above s, or makes s the only screen if it is not
+// on the stack.
+func (m *model) backTo(s screen) {
+	for i := len(m.screens) - 1; i >= 0; i-- {
+		if m.screens[i] == s {
+			m.screens = m.screens[:i+1]
+			return
+		}
+	}
+	m.screens = []screen{s}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tuitype/internal/config"
+	"tuitype/internal/keymap"
+	"tuitype/internal/prompt"
+	"tuitype/internal/theme"
+)
+
+// settingField is one editable entry of the settings screen. get formats
+// the entry of a config as text and set parses it back.
+type settingField struct {
+	label string
+	get   func(config.AppConfig) string
+	set   func(*config.AppConfig, string) error
+	// choices, if set, are cycled through instead of typed.
+	choices func(config.AppConfig) []string
+}
+
+var settingFields = []settingField{
+	{
+		label: "Durations",
+		get:   func(c config.AppConfig) string { return strings.Join(c.Durations, " ") },
+		set: func(c *config.AppConfig, s string) error {
+			c.Durations = strings.Fields(s)
+			return nil
+		},
+	},
+	{
+		label: "Word counts",
+		get: func(c config.AppConfig) string {
+			counts := make([]string, len(c.WordCounts))
+			for i, n := range c.WordCounts {
+				counts[i] = strconv.Itoa(n)
+			}
+			return strings.Join(counts, " ")
+		},
+		set: func(c *config.AppConfig, s string) error {
+			counts := []int{}
+			for _, f := range strings.Fields(s) {
+				n, err := strconv.Atoi(f)
+				if err != nil {
+					return fmt.Errorf("word count %q is not a number", f)
+				}
+				counts = append(counts, n)
+			}
+			c.WordCounts = counts
+			return nil
+		},
+	},
+	{
+		label: "Prompt words",
+		get:   func(c config.AppConfig) string { return strconv.Itoa(c.PromptWordCount) },
+		set: func(c *config.AppConfig, s string) error {
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("prompt words %q is not a number", s)
+			}
+			c.PromptWordCount = n
+			return nil
+		},
+	},
+	{
+		label: "Quote endpoint",
+		get:   func(c config.AppConfig) string { return c.QuoteEndpoint },
+		set: func(c *config.AppConfig, s string) error {
+			c.QuoteEndpoint = strings.TrimSpace(s)
+			return nil
+		},
+	},
+	{
+		label: "Code endpoints",
+		get: func(c config.AppConfig) string {
+			endpoints := map[string]string{}
+			if c.GoExampleEndpoint != "" {
+				endpoints["go"] = c.GoExampleEndpoint
+			}
+			for lang, url := range c.CodeEndpoints {
+				endpoints[lang] = url
+			}
+			var pairs []string
+			for _, lang := range config.CodeLanguages {
+				if url := strings.TrimSpace(endpoints[lang]); url != "" {
+					pairs = append(pairs, lang+"="+url)
+				}
+			}
+			return strings.Join(pairs, " ")
+		},
+		set: func(c *config.AppConfig, s string) error {
+			endpoints := map[string]string{}
+			for _, f := range strings.Fields(s) {
+				lang, url, ok := strings.Cut(f, "=")
+				if !ok || url == "" {
+					return fmt.Errorf("code endpoint %q is not language=url", f)
+				}
+				endpoints[lang] = url
+			}
+			c.CodeEndpoints = endpoints
+			// The older key would otherwise bring a removed Go endpoint back.
+			c.GoExampleEndpoint = ""
+			return nil
+		},
+	},
+	{
+		label: "Theme",
+		get: func(c config.AppConfig) string {
+			if c.Theme == "" {
+				return theme.Default
+			}
+			return c.Theme
+		},
+		set: func(c *config.AppConfig, s string) error {
+			c.Theme = s
+			return nil
+		},
+		choices: func(c config.AppConfig) []string {
+			custom := make([]string, 0, len(c.Themes))
+			for name := range c.Themes {
+				custom = append(custom, name)
+			}
+			sort.Strings(custom)
+			return append(theme.Names(), custom...)
+		},
+	},
+	{
+		label: "Normal words",
+		get:   func(c config.AppConfig) string { return strings.Join(c.NormalWords, " ") },
+		set: func(c *config.AppConfig, s string) error {
+			c.NormalWords = strings.Fields(s)
+			return nil
+		},
+	},
+	{
+		label: "Special chars",
+		get:   func(c config.AppConfig) string { return strings.Join(c.SpecialCharWords, " ") },
+		set: func(c *config.AppConfig, s string) error {
+			c.SpecialCharWords = strings.Fields(s)
+			return nil
+		},
+	},
+}
+
+// settingsForm is the state of the settings screen: a copy of the config
+// file being edited, which is only written on save.
+type settingsForm struct {
+	cfg      config.AppConfig
+	selected int
+	editing  bool
+	input    []rune
+	err      error
+}
+
+// settingsSavedMsg reports the outcome of writing the config file.
+type settingsSavedMsg struct {
+	cfg config.AppConfig
+	err error
+}
+
+// openSettings switches from the mode menu to the settings screen, editing
+// the config file as it is on disk.
+func (m *model) openSettings() {
+	m.push(screenSettings)
+	cfg, err := config.Read(m.configPath)
+	if err != nil {
+		cfg = config.Default()
+	}
+	m.settings = &settingsForm{cfg: cfg, err: err}
+}
+
+// updateSettingsKey edits the form. The row after the fields saves it.
+func (m model) updateSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f, keys := *m.settings, m.keys()
+	m.settings = &f
+	if f.editing {
+		// Text comes first: select may be bound to a printable key.
+		switch {
+		case msg.Type == tea.KeyRunes:
+			f.input = append(f.input, msg.Runes...)
+		case msg.Type == tea.KeySpace:
+			f.input = append(f.input, ' ')
+		case keymap.Matches(msg, keys.Select):
+			if err := settingFields[f.selected].set(&f.cfg, string(f.input)); err != nil {
+				f.err = err
+				return m, nil
+			}
+			f.editing, f.err = false, nil
+		case keymap.Matches(msg, keys.Back):
+			f.editing, f.err = false, nil
+		case msg.Type == tea.KeyBackspace:
+			if len(f.input) > 0 {
+				f.input = f.input[:len(f.input)-1]
+			}
+		}
+		return m, nil
+	}
+
+	switch {
+	case keymap.Matches(msg, keys.MenuPrev):
+		f.selected = (f.selected + len(settingFields)) % (len(settingFields) + 1)
+	case keymap.Matches(msg, keys.MenuNext):
+		f.selected = (f.selected + 1) % (len(settingFields) + 1)
+	case keymap.Matches(msg, keys.Select):
+		if f.selected == len(settingFields) {
+			return m, m.saveSettings()
+		}
+		field := settingFields[f.selected]
+		if field.choices == nil {
+			f.editing = true
+			f.input = []rune(field.get(f.cfg))
+			return m, nil
+		}
+		choices := field.choices(f.cfg)
+		next := 0
+		for i, c := range choices {
+			if c == field.get(f.cfg) {
+				next = (i + 1) % len(choices)
+			}
+		}
+		field.set(&f.cfg, choices[next])
+	case keymap.Matches(msg, keys.Back):
+		m.back()
+		m.settings = nil
+	}
+	return m, nil
+}
+
+// saveSettings validates the form and writes it to the config file in the
+// background.
+func (m *model) saveSettings() tea.Cmd {
+	cfg, path := m.settings.cfg, m.configPath
+	if _, err := config.Resolve(cfg); err != nil {
+		m.settings.err = err
+		return nil
+	}
+	if path == "" {
+		m.settings.err = fmt.Errorf("no config file to save to")
+		return nil
+	}
+	return func() tea.Msg {
+		return settingsSavedMsg{cfg: cfg, err: config.Save(path, cfg)}
+	}
+}
+
+// applySettings takes the saved settings into use and returns to the mode
+// menu. Overrides from flags and the environment stay in force.
+func (m *model) applySettings(cfg config.AppConfig) {
+	rc, err := config.Resolve(cfg)
+	if err != nil {
+		m.settings.err = err
+		return
+	}
+	m.cfg.Words = rc.Words
+	m.cfg.SpecialCharWords = rc.SpecialCharWords
+	m.cfg.DurationOptions = rc.DurationOptions
+	m.cfg.DurationLabels = rc.DurationLabels
+	m.cfg.WordCounts = rc.WordCounts
+	m.cfg.PromptWordCount = rc.PromptWordCount
+	m.cfg.QuoteEndpoint = rc.QuoteEndpoint
+	m.cfg.CodeEndpoints = rc.CodeEndpoints
+	if m.cfg.CodeDir != "" {
+		delete(m.cfg.CodeEndpoints, "go")
+	}
+	if os.Getenv("NO_COLOR") == "" {
+		m.cfg.Theme = rc.Theme
+	}
+
+	lang := m.prompts.Language().Name
+	m.prompts = newPromptService(m.cfg)
+	m.prompts.SetLanguage(lang)
+	if m.text != nil {
+		m.prompts.SetText(m.text.chunks)
+	}
+	m.queue = prompt.NewQueue(prefetchSize)
+
+	m.lengths, m.lengthLabels = lengthOptions(m.cfg)
+	m.selectedOption = 0
+	for i, l := range m.lengths {
+		if l == m.length {
+			m.selectedOption = i
+		}
+	}
+	m.length = m.lengths[m.selectedOption]
+	m.settings = nil
+	m.back()
+}
+
+// settingValue is the text shown for field i, cut to width.
+func (f *settingsForm) settingValue(i, width int) string {
+	v := []rune(settingFields[i].get(f.cfg))
+	if width > 1 && len(v) > width {
+		return string(v[:width-1]) + "…"
+	}
+	return string(v)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tuitype/internal/config"
+)
+
+// pressKeys sends keys to tm, running the commands that save settings.
+func pressKeys(tm tea.Model, keys ...string) tea.Model {
+	for _, k := range keys {
+		var cmd tea.Cmd
+		tm, cmd = tm.Update(keyMsg(k))
+		if cmd == nil {
+			continue
+		}
+		if msg, ok := cmd().(settingsSavedMsg); ok {
+			tm, _ = tm.Update(msg)
+		}
+	}
+	return tm
+}
+
+func TestSettingsSaveConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"durations": ["30s"], "team_note": "keep me"}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	m := initialModel(cfg, nil)
+	m.configPath = path
+	m.screens = []screen{screenModes}
+	m.width, m.height = 100, 30
+
+	// Prompt words is the third field; Save follows the last field.
+	tm := pressKeys(m, "s", "j", "j", "enter", "backspace", "backspace", "1", "2", "enter")
+	if view := tm.(model).View(); !strings.Contains(view, "Prompt words") {
+		t.Fatalf("settings view lacks the fields:\n%s", view)
+	}
+	tm = pressKeys(tm, "k", "k", "k", "enter")
+	m = tm.(model)
+	if m.screen() != screenModes || m.cfg.PromptWordCount != 12 {
+		t.Fatalf("screen %v, prompt words %d; want the mode menu with 12 words", m.screen(), m.cfg.PromptWordCount)
+	}
+
+	var file map[string]any
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("saved config is not JSON: %v", err)
+	}
+	if file["team_note"] != "keep me" || file["prompt_word_count"] != 12.0 || len(file) != 3 {
+		t.Fatalf("saved config = %s", data)
+	}
+
+	// An invalid duration is reported and nothing is written.
+	tm = pressKeys(m, "s", "enter", "x", "enter", "k", "enter")
+	m = tm.(model)
+	if m.screen() != screenSettings || m.settings.err == nil || !strings.Contains(m.settings.err.Error(), "invalid duration") {
+		t.Fatalf("screen %v, err %v; want the settings with a duration error", m.screen(), m.settings.err)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(data) {
+		t.Fatal("an invalid setting must not be saved")
+	}
+	if m = pressKeys(m, "esc").(model); m.screen() != screenModes || m.cfg.DurationLabels[0] != "30s" {
+		t.Fatalf("esc should discard the settings, durations = %q", m.cfg.DurationLabels)
+	}
+}
+
+func TestSettingsEditUsesKeyBindings(t *testing.T) {
+	cfg, err := config.Resolve(config.AppConfig{
+		NormalWords:      []string{"a"},
+		SpecialCharWords: []string{"!"},
+		Durations:        []string{"30s"},
+		PromptWordCount:  5,
+		Keybindings:      map[string][]string{"back-to-menu": {"ctrl+a"}, "select": {"l", "enter"}},
+	})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	m := initialModel(cfg, nil)
+	m.configPath = filepath.Join(t.TempDir(), "config.json")
+	m.screens = []screen{screenModes}
+	m.width, m.height = 100, 30
+
+	m = pressKeys(m, "s").(model)
+	before := m.settings.settingValue(0, 0)
+	m = pressKeys(m, "enter", "x").(model)
+	if view := m.View(); !strings.Contains(view, "ctrl+a cancel") {
+		t.Fatalf("edit help does not show the bound key:\n%s", view)
+	}
+	m = pressKeys(m, "ctrl+a").(model)
+	if m.settings.editing || m.settings.settingValue(0, 0) != before {
+		t.Fatalf("the back binding should cancel the edit, editing %v", m.settings.editing)
+	}
+
+	// A printable select key is typed while editing, not applied.
+	m = pressKeys(m, "l", " ", "l").(model)
+	if !m.settings.editing || string(m.settings.input) != before+" l" {
+		t.Fatalf("editing %v, input %q; want %q typed", m.settings.editing, string(m.settings.input), before+" l")
+	}
+	m = pressKeys(m, "enter").(model)
+	if m.settings.editing || m.settings.settingValue(0, 0) != before+" l" {
+		t.Fatalf("enter should apply the edit, value %q", m.settings.settingValue(0, 0))
+	}
+}
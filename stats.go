@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tuitype/internal/history"
+	"tuitype/internal/prompt"
+)
+
+func runStats(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	historyPath := fs.String("history", defaultHistoryPath(), "path to session history file")
+	days := fs.Int("days", 7, "trend window in days")
+	mode := fs.String("mode", "", "only report this mode (normal, special, quote, code, weak, text)")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:\n  %s stats [options]\n\nOptions:\n", strings.ToLower(appName))
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if *days <= 0 {
+		fmt.Fprintln(stderr, "stats: -days must be > 0")
+		return 2
+	}
+
+	sessions, err := history.NewStore(*historyPath).Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "stats: %v\n", err)
+		return 1
+	}
+	if *mode != "" {
+		filtered := sessions[:0]
+		for _, s := range sessions {
+			if s.Mode == *mode {
+				filtered = append(filtered, s)
+			}
+		}
+		sessions = filtered
+	}
+
+	summaries := history.Summarize(sessions, time.Now(), *days)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summaries); err != nil {
+			fmt.Fprintf(stderr, "stats: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if len(summaries) == 0 {
+		fmt.Fprintln(stdout, "no sessions recorded yet")
+		return 0
+	}
+	writeStatsTable(stdout, summaries, *days)
+	return 0
+}
+
+func writeStatsTable(out io.Writer, summaries []history.Summary, days int) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "MODE\tLENGTH\tTESTS\tBEST\tAVG\tMEDIAN\tACC\tLAST %dd\tTREND/DAY\tACC BANDS\n", days)
+	for _, s := range summaries {
+		mode := modeLabel(s.Mode)
+		if s.LegacyWPM {
+			mode += " (old WPM)"
+		}
+		recent := "-"
+		trend := "-"
+		if s.Trend.Sessions > 0 {
+			recent = fmt.Sprintf("%.1f (%d)", s.Trend.AverageWPM, s.Trend.Sessions)
+		}
+		if s.Trend.Sessions > 1 {
+			trend = fmt.Sprintf("%+.2f", s.Trend.WPMPerDay)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f%%\t%s\t%s\t%s\n",
+			mode, summaryLength(s), s.Sessions, s.BestWPM, s.AverageWPM, s.MedianWPM,
+			s.AvgAccuracy, recent, trend, accuracyBands(s.AccuracyCounts))
+	}
+	tw.Flush()
+}
+
+func accuracyBands(counts []int) string {
+	parts := make([]string, 0, len(counts))
+	for i, n := range counts {
+		bands := history.AccuracyBands
+		var label string
+		switch {
+		case i+1 == len(bands):
+			label = fmt.Sprintf("%.0f+", bands[i])
+		case i == 0:
+			label = fmt.Sprintf("<%.0f", bands[1])
+		default:
+			label = fmt.Sprintf("%.0f-%.0f", bands[i], bands[i+1])
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d", label, n))
+	}
+	return strings.Join(parts, " ")
+}
+
+func summaryLength(s history.Summary) string {
+	return lengthOf(history.Session{Kind: s.Kind, Duration: s.Duration, Words: s.Words}).String()
+}
+
+func modeLabel(name string) string {
+	if m, ok := prompt.ModeByName(name); ok {
+		return prompt.ModeLabels()[m]
+	}
+	return name
+}
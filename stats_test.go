@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tuitype/internal/history"
+)
+
+func TestRunStatsJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := history.NewStore(path)
+	for _, wpm := range []float64{40, 60} {
+		if err := store.Append(history.Session{Mode: "normal", Duration: 30 * time.Second, StartedAt: time.Now(), WPM: wpm, Accuracy: 95}); err != nil {
+			t.Fatalf("Append returned error: %v", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runStats([]string{"-history", path, "-json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runStats exit = %d, stderr = %s", code, stderr.String())
+	}
+	var got []history.Summary
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if len(got) != 1 || got[0].BestWPM != 60 || got[0].AverageWPM != 50 {
+		t.Fatalf("summaries = %+v", got)
+	}
+}
+
+func TestRunStatsTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := history.NewStore(path).Append(history.Session{Mode: "code", Duration: time.Minute, StartedAt: time.Now(), WPM: 42, Accuracy: 99}); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runStats([]string{"-history", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runStats exit = %d, stderr = %s", code, stderr.String())
+	}
+	if out := stdout.String(); !strings.Contains(out, "Code Practice") || !strings.Contains(out, "42.0") {
+		t.Fatalf("table output missing row:\n%s", out)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tuitype/internal/prompt"
+)
+
+// testCode identifies a reproducible test: everyone who starts the same
+// code types the same locally generated prompts for the same length. It is
+// written as mode:length:seed, e.g. "normal:30s:1y2p0ij32e8e7", where the
+// length is a duration, a word count such as "50w", or "1p" for a single
+// prompt. Code Practice names its language after the mode, e.g.
+// "code/python:30s:1y2p0ij32e8e7"; a plain "code" means Go.
+type testCode struct {
+	mode     prompt.Mode
+	language string
+	length   testLength
+	seed     int64
+}
+
+func (c testCode) String() string {
+	mode := c.mode.Name()
+	if c.language != "" {
+		mode += "/" + c.language
+	}
+	return fmt.Sprintf("%s:%s:%s", mode, c.length.code(), strconv.FormatInt(c.seed, 36))
+}
+
+func parseTestCode(s string) (testCode, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return testCode{}, fmt.Errorf("test code %q: want mode:length:seed", s)
+	}
+	name, language, hasLanguage := strings.Cut(parts[0], "/")
+	mode, ok := prompt.ModeByName(name)
+	if !ok {
+		return testCode{}, fmt.Errorf("test code %q: unknown mode %q", s, name)
+	}
+	switch {
+	case mode != prompt.ModeCode && hasLanguage:
+		return testCode{}, fmt.Errorf("test code %q: only code has a language", s)
+	case mode == prompt.ModeCode && !hasLanguage:
+		language = prompt.Languages()[0].Name
+	case mode == prompt.ModeCode:
+		if _, ok := prompt.LanguageByName(language); !ok {
+			return testCode{}, fmt.Errorf("test code %q: unknown language %q", s, language)
+		}
+	}
+	length, err := parseLength(parts[1])
+	if err != nil {
+		return testCode{}, fmt.Errorf("test code %q: %v", s, err)
+	}
+	seed, err := strconv.ParseInt(parts[2], 36, 64)
+	if err != nil || seed == 0 {
+		return testCode{}, fmt.Errorf("test code %q: invalid seed %q", s, parts[2])
+	}
+	return testCode{mode: mode, language: language, length: length, seed: seed}, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"tuitype/internal/config"
+	"tuitype/internal/history"
+	"tuitype/internal/prompt"
+)
+
+func TestTestCodeRoundTrip(t *testing.T) {
+	code := testCode{mode: prompt.ModeSpecialChars, length: timeLength(time.Minute), seed: 1760000000123456789}
+	got, err := parseTestCode(code.String())
+	if err != nil {
+		t.Fatalf("parseTestCode(%q) returned error: %v", code.String(), err)
+	}
+	if got != code {
+		t.Fatalf("round trip = %+v, want %+v", got, code)
+	}
+}
+
+func TestTestCodeWordAndPromptLengths(t *testing.T) {
+	for _, s := range []string{"normal:50w:9ix", "quote:1p:9ix", "code/python:30s:9ix"} {
+		code, err := parseTestCode(s)
+		if err != nil {
+			t.Fatalf("parseTestCode(%q) returned error: %v", s, err)
+		}
+		if code.String() != s {
+			t.Fatalf("String() = %q, want %q", code.String(), s)
+		}
+	}
+	code, _ := parseTestCode("normal:50w:9ix")
+	if code.length.kind != history.KindWords || code.length.words != 50 {
+		t.Fatalf("length = %+v, want 50 words", code.length)
+	}
+}
+
+func TestParseTestCodeRejectsGarbage(t *testing.T) {
+	for _, s := range []string{"", "normal:30s", "bogus:30s:abc", "normal:-1s:abc", "normal:0w:abc", "normal:2p:abc", "normal:30s:!!", "normal:30s:0", "normal/go:30s:abc", "code/cobol:30s:abc"} {
+		if _, err := parseTestCode(s); err == nil {
+			t.Fatalf("parseTestCode(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestFixedSeedRepeatsPrompts(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	cfg.Seed = 99
+	a := initialModel(cfg, nil)
+	a.resetSession()
+	b := initialModel(cfg, nil)
+	b.resetSession()
+	if a.seed != 99 || a.session.Snapshot().Prompt != b.session.Snapshot().Prompt {
+		t.Fatalf("fixed seed produced different prompts: %q vs %q", a.session.Snapshot().Prompt, b.session.Snapshot().Prompt)
+	}
+}
+
+func TestStartTestAppliesCode(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	code := testCode{mode: prompt.ModeSpecialChars, length: timeLength(15 * time.Second), seed: 12345}
+	a := initialModel(cfg, nil)
+	a.startTest(code)
+	b := initialModel(cfg, nil)
+	b.startTest(code)
+	if a.screen() != screenTest {
+		t.Fatal("startTest should skip the menus")
+	}
+	if a.testCode() != code {
+		t.Fatalf("testCode() = %+v, want %+v", a.testCode(), code)
+	}
+	if a.session.Snapshot().Prompt != b.session.Snapshot().Prompt {
+		t.Fatal("same test code produced different prompts")
+	}
+	// The code's seed is for that test only: a new prompt is random again.
+	a.restart(false)
+	if a.cfg.Seed != 0 || a.seed == code.seed {
+		t.Fatalf("seed after restart = %d, config seed %d; want a fresh seed", a.seed, a.cfg.Seed)
+	}
+}
+
+func TestStartTestAppliesCodeLanguage(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	code, err := parseTestCode("code/python:15s:9ix")
+	if err != nil {
+		t.Fatalf("parseTestCode returned error: %v", err)
+	}
+	m := initialModel(cfg, nil)
+	m.startTest(code)
+	if m.prompts.Language().Name != "python" || m.testCode() != code {
+		t.Fatalf("language %q, test code %v; want the python code", m.prompts.Language().Name, m.testCode())
+	}
+	if old, _ := parseTestCode("code:15s:9ix"); old.language != "go" {
+		t.Fatalf("plain code language = %q, want go", old.language)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"time"
+
+	"tuitype/internal/engine"
+	"tuitype/internal/metrics"
+	"tuitype/internal/prompt"
+)
+
+// weakErrorWeight is how much a 100% error rate adds to a character's
+// weakness; a key typed twice as slowly as average adds 1.
+const weakErrorWeight = 4
+
+// weaknessFrom turns aggregated keystrokes into weak keys mode weights.
+// Characters are weighted by error rate and by how much slower than the
+// average key they are typed, bigrams by how much slower than the average
+// transition. Keys and bigrams with fewer than heatmapMinSamples samples
+// are ignored.
+func weaknessFrom(report *metrics.KeyReport) prompt.Weakness {
+	w := prompt.Weakness{Chars: map[rune]float64{}, Bigrams: map[[2]rune]float64{}}
+	var total time.Duration
+	var timed int
+	for _, k := range report.Keys {
+		total += k.Latency
+		timed += k.Timed
+	}
+	for _, k := range report.Keys {
+		if k.Presses < heatmapMinSamples {
+			continue
+		}
+		score := k.ErrorRate() / 100 * weakErrorWeight
+		if timed > 0 && k.Timed > 0 {
+			score += slowness(k.AvgLatency(), total/time.Duration(timed))
+		}
+		if score > 0 {
+			w.Chars[k.Key] = score
+		}
+	}
+
+	total, timed = 0, 0
+	for _, b := range report.Bigrams {
+		total += b.Total
+		timed += b.Samples
+	}
+	for _, b := range report.Bigrams {
+		if b.Samples < heatmapMinSamples {
+			continue
+		}
+		if score := slowness(b.Avg(), total/time.Duration(timed)); score > 0 {
+			w.Bigrams[b.Bigram] = score
+		}
+	}
+	return w
+}
+
+// slowness is how many times longer than avg d is, beyond the first.
+func slowness(d, avg time.Duration) float64 {
+	if avg <= 0 || d <= avg {
+		return 0
+	}
+	return float64(d)/float64(avg) - 1
+}
+
+// loadWeakness aggregates the saved keystroke logs once; later sessions
+// are folded in by learnWeakness as they complete.
+func (m *model) loadWeakness() {
+	if m.weak != nil {
+		return
+	}
+	m.weak = &metrics.KeyReport{}
+	if m.history == nil {
+		return
+	}
+	if sessions, err := m.history.Load(); err == nil {
+		for _, s := range sessions {
+			m.weak.Add(s.Keystrokes)
+		}
+	}
+}
+
+// learnWeakness updates the weak keys weights with a completed session.
+func (m *model) learnWeakness(events []engine.Event) {
+	if m.weak == nil {
+		return
+	}
+	m.weak.Add(events)
+	m.prompts.SetWeakness(weaknessFrom(m.weak))
+}
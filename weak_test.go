@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"tuitype/internal/config"
+	"tuitype/internal/history"
+	"tuitype/internal/metrics"
+	"tuitype/internal/prompt"
+)
+
+func TestWeaknessFrom(t *testing.T) {
+	var report metrics.KeyReport
+	report.Add(slowBraceSession().Keystrokes)
+	w := weaknessFrom(&report)
+
+	// '{' is typed six times slower than 'a'; '|' was only pressed once.
+	if w.Chars['{'] <= 0 || w.Chars['a'] != 0 {
+		t.Fatalf("Chars = %v, want only { weak", w.Chars)
+	}
+	if _, ok := w.Chars['|']; ok {
+		t.Fatal("key with too few samples was weighted")
+	}
+	if w.Bigrams[[2]rune{'a', '{'}] <= 0 || w.Bigrams[[2]rune{'{', 'a'}] != 0 {
+		t.Fatalf("Bigrams = %v, want only a{ weak", w.Bigrams)
+	}
+	if got := weaknessFrom(&metrics.KeyReport{}); len(got.Chars) != 0 || len(got.Bigrams) != 0 {
+		t.Fatalf("empty report weakness = %+v", got)
+	}
+}
+
+func TestWeakModeLearnsFromCompletedSessions(t *testing.T) {
+	cfg, err := config.Resolve(config.Default())
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	store := history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err := store.Append(slowBraceSession()); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+
+	m := initialModel(cfg, store)
+	m.selectedMode = prompt.ModeWeak
+	m.resetSession()
+	if m.weak == nil || m.weak.Key('{').Presses != 3 {
+		t.Fatalf("weak report not loaded from history: %+v", m.weak)
+	}
+
+	m.session = slowBraceTyping()
+	m.finish(m.session.Snapshot().StartedAt)
+	if got := m.weak.Key('{').Presses; got != 6 {
+		t.Fatalf("presses of { after a completed session = %d, want 6", got)
+	}
+}